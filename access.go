@@ -0,0 +1,85 @@
+package gofile
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// AccessMode is a set of permissions checked by Access.
+type AccessMode uint32
+
+const (
+	AccessExist   AccessMode = 0 // the file exists
+	AccessExecute AccessMode = 1
+	AccessWrite   AccessMode = 2
+	AccessRead    AccessMode = 4
+)
+
+// Access checks whether the calling process may access
+// the named file with mode, using its effective user
+// and group IDs. It returns nil if the access is
+// allowed; a denied access returns a GoFileError
+// wrapping ErrPermission.
+func Access(name string, mode AccessMode) error {
+	if err := access(name, mode); err != nil {
+		return NewGoFileError("access denied", name, err)
+	}
+	return nil
+}
+
+// CanRead reports whether the calling process may read
+// the named file.
+func CanRead(name string) bool { return Access(name, AccessRead) == nil }
+
+// CanWrite reports whether the calling process may
+// write the named file.
+func CanWrite(name string) bool { return Access(name, AccessWrite) == nil }
+
+// CanExecute reports whether the calling process may
+// execute the named file, or search it if it is a
+// directory.
+func CanExecute(name string) bool { return Access(name, AccessExecute) == nil }
+
+// accessInfo checks mode against the permission bits
+// in fi, for files where access(2) cannot be used.
+// Where the owner is not known, the owner bits are used.
+func accessInfo(fi fs.FileInfo, mode AccessMode) error {
+	perm := fi.Mode()
+	if st, ok := statSys(fi); ok {
+		groups, _ := os.Getgroups()
+		groups = append(groups, os.Getegid())
+		if permits(perm, st.uid, st.gid, os.Geteuid(), groups, mode) {
+			return nil
+		}
+	} else if want := fs.FileMode(mode & 7); perm>>6&want == want {
+		return nil
+	}
+	return syscall.EACCES
+}
+
+// permits reports whether a process with the effective
+// user ID euid and the group IDs groups may access a
+// file with mode, owned by uid and gid, as the kernel
+// decides. Only one class of permission bits applies.
+func permits(perm fs.FileMode, uid, gid uint32, euid int, groups []int, mode AccessMode) bool {
+	want := fs.FileMode(mode & 7)
+	if euid == 0 {
+		// root may read and write anything, and execute
+		// anything that is executable by someone
+		return want&1 == 0 || perm.IsDir() || perm&0111 != 0
+	}
+
+	bits := perm & 7
+	if uint32(euid) == uid {
+		bits = perm >> 6 & 7
+	} else {
+		for _, g := range groups {
+			if uint32(g) == gid {
+				bits = perm >> 3 & 7
+				break
+			}
+		}
+	}
+	return bits&want == want
+}
@@ -0,0 +1,9 @@
+package gofile
+
+import "golang.org/x/sys/unix"
+
+// access uses faccessat(2) with AT_EACCESS, so that the
+// effective IDs are checked, as for an open.
+func access(name string, mode AccessMode) error {
+	return unix.Faccessat(unix.AT_FDCWD, name, uint32(mode), unix.AT_EACCESS)
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package gofile
+
+import "os"
+
+// access evaluates the permission bits of the file
+// against the effective user ID and the groups of the
+// process.
+func access(name string, mode AccessMode) error {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return accessInfo(fi, mode)
+}
@@ -0,0 +1,82 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermits(t *testing.T) {
+	const uid, gid = 1000, 100
+
+	tests := []struct {
+		name   string
+		perm   os.FileMode
+		euid   int
+		groups []int
+		mode   AccessMode
+		want   bool
+	}{
+		{"owner read", 0400, uid, nil, AccessRead, true},
+		{"owner write denied", 0400, uid, nil, AccessWrite, false},
+		{"owner bits only apply", 0074, uid, []int{gid}, AccessRead, false},
+		{"group read", 0040, 2000, []int{gid}, AccessRead, true},
+		{"supplementary group", 0020, 2000, []int{5, gid}, AccessWrite, true},
+		{"group bits only apply", 0704, 2000, []int{gid}, AccessRead, false},
+		{"other read", 0004, 2000, []int{5}, AccessRead, true},
+		{"other read denied", 0640, 2000, []int{5}, AccessRead, false},
+		{"read and write", 0600, uid, nil, AccessRead | AccessWrite, true},
+		{"read and execute", 0600, uid, nil, AccessRead | AccessExecute, false},
+		{"exists", 0, 2000, nil, AccessExist, true},
+		{"root read", 0, 0, nil, AccessRead | AccessWrite, true},
+		{"root execute", 0001, 0, nil, AccessExecute, true},
+		{"root execute denied", 0666, 0, nil, AccessExecute, false},
+		{"root search", os.ModeDir, 0, nil, AccessExecute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permits(tt.perm, uid, gid, tt.euid, tt.groups, tt.mode); got != tt.want {
+				t.Errorf("permits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccess(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "file", "script", "secret")
+	file := filepath.Join(dir, "file")
+	script := filepath.Join(dir, "script")
+	secret := filepath.Join(dir, "secret")
+	os.Chmod(file, 0644)
+	os.Chmod(script, 0755)
+	os.Chmod(secret, 0)
+
+	if !CanRead(file) || !CanWrite(file) || CanExecute(file) {
+		t.Errorf("CanRead, CanWrite, CanExecute(%s) = %v %v %v, want true true false",
+			file, CanRead(file), CanWrite(file), CanExecute(file))
+	}
+	if !CanExecute(script) || !CanExecute(dir) {
+		t.Error("CanExecute() = false for an executable file or directory")
+	}
+	if err := Access(filepath.Join(dir, "missing"), AccessExist); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Access() error = %v, want ErrNotExist", err)
+	}
+	if err := Access(file, AccessExecute); !errors.Is(err, ErrPermission) {
+		t.Errorf("Access() error = %v, want ErrPermission", err)
+	}
+	if os.Geteuid() != 0 && CanRead(secret) {
+		t.Errorf("CanRead(%s) = true for mode 0", secret)
+	}
+
+	if _, err := StatCheck(file); err != nil {
+		t.Errorf("StatCheck() error = %v", err)
+	}
+	if _, err := StatCheck(file, WithAccess(AccessExecute)); !errors.Is(err, ErrPermission) {
+		t.Errorf("StatCheck(WithAccess(AccessExecute)) error = %v, want ErrPermission", err)
+	}
+	if _, err := StatCheck(script, WithAccess(AccessRead|AccessExecute)); err != nil {
+		t.Errorf("StatCheck(WithAccess(AccessRead|AccessExecute)) error = %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// SafeJoin joins the untrusted relative path to root
+// and returns the result, with any symbolic links
+// resolved, if it stays inside root. The path need
+// not exist.
+//
+// Absolute paths, paths that climb out of root with
+// ".." and symbolic links that point outside root
+// return a GoFileError wrapping ErrPermission.
+//
+// On Linux, links in existing paths are resolved by the
+// kernel with openat2 and RESOLVE_BENEATH; otherwise
+// each component is checked in turn. Either way the
+// result is only a name: the files may be changed
+// between the check and the use of the name, so a link
+// swapped in meanwhile can still lead outside root.
+// BasePathFS opens files without that race on Linux.
+func SafeJoin(root, untrusted string) (string, error) {
+	rel, err := cleanRelative(untrusted)
+	if err != nil {
+		return "", err
+	}
+	return joinBeneath(OSFS{}, filepath.Clean(root), rel, true)
+}
+
+// cleanRelative returns the cleaned form of the
+// untrusted relative path name.
+func cleanRelative(name string) (string, error) {
+	if strings.IndexByte(name, 0) >= 0 {
+		return "", NewGoFileError("path contains a NUL byte", name, ErrInvalid)
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(filepath.ToSlash(name), "/") {
+		return "", NewGoFileError("absolute path not allowed", name, ErrPermission)
+	}
+	rel := filepath.Clean(name)
+	if escapes(rel) {
+		return "", NewGoFileError("path escapes root", name, ErrPermission)
+	}
+	return rel, nil
+}
+
+// escapes reports whether the cleaned relative path
+// rel leaves the directory it is relative to.
+func escapes(rel string) bool {
+	return rel == ".." || strings.HasPrefix(filepath.ToSlash(rel), "../")
+}
+
+// joinBeneath joins the cleaned relative path rel to
+// root in fsys, resolving symbolic links inside root.
+// A link at the end of rel is resolved if follow is set.
+func joinBeneath(fsys FileSystem, root, rel string, follow bool) (string, error) {
+	if isOS(fsys) {
+		if p, ok, err := resolveBeneath(root, rel, follow); ok {
+			return p, err
+		}
+	}
+	return resolveIn(fsys, root, rel, follow)
+}
+
+// resolveIn is joinBeneath checking one component of
+// rel at a time. Components after the first missing
+// one are joined as they are.
+func resolveIn(fsys FileSystem, root, rel string, follow bool) (string, error) {
+	var parts, resolved []string
+	if rel != "." {
+		parts = strings.Split(filepath.ToSlash(rel), "/")
+	}
+
+	for i, hops := 0, 0; i < len(parts); i++ {
+		name := filepath.Join(root, filepath.FromSlash(path.Join(append(resolved, parts[i])...)))
+		fi, err := fsys.Lstat(name)
+		if errors.Is(err, ErrNotExist) {
+			resolved = append(resolved, parts[i:]...)
+			break
+		}
+		if err != nil {
+			return "", NewGoFileError("unable to resolve path", name, err)
+		}
+
+		if fi.Mode()&fs.ModeSymlink != 0 && (i < len(parts)-1 || follow) {
+			if hops++; hops > maxSymlinks {
+				return "", NewGoFileError("too many symbolic links", rel, syscall.ELOOP)
+			}
+			target, err := fsys.Readlink(name)
+			if err != nil {
+				return "", NewGoFileError("unable to read symbolic link", name, err)
+			}
+			target = filepath.ToSlash(target)
+			if path.IsAbs(target) {
+				return "", NewGoFileError("symbolic link escapes root", name, ErrPermission)
+			}
+
+			// continue from the root with the link replaced
+			// by its target
+			next := path.Join(append(append(resolved, target), parts[i+1:]...)...)
+			if escapes(next) {
+				return "", NewGoFileError("symbolic link escapes root", name, ErrPermission)
+			}
+			parts, resolved, i = strings.Split(next, "/"), nil, -1
+			continue
+		}
+		resolved = append(resolved, parts[i])
+	}
+	return filepath.Join(root, filepath.FromSlash(path.Join(resolved...))), nil
+}
+
+// BasePathFS is a FileSystem restricted to the
+// directory Root of another FileSystem, as with
+// chroot. Names are relative to Root, with or
+// without a leading slash, and cannot refer to
+// files outside it, with ".." or through symbolic
+// links; such attempts return a GoFileError wrapping
+// ErrPermission.
+//
+// On Linux, Open and OpenFile of the host file system
+// resolve the name as the file is opened, with openat2
+// and RESOLVE_BENEATH, so links changed concurrently
+// cannot escape Root. Other operations, and opens
+// elsewhere, resolve the name as SafeJoin does and
+// then use it, and so may race with such changes.
+type BasePathFS struct {
+	Root string
+	FS   FileSystem
+}
+
+// NewBasePathFS returns a BasePathFS for the directory
+// root of fsys, or of the host file system if fsys
+// is nil.
+func NewBasePathFS(fsys FileSystem, root string) *BasePathFS {
+	return &BasePathFS{Root: filepath.Clean(root), FS: fsOrOS(fsys)}
+}
+
+// rel returns the cleaned name relative to Root of
+// the named file.
+func (b *BasePathFS) rel(name string) (string, error) {
+	return cleanRelative(filepath.FromSlash(strings.TrimLeft(filepath.ToSlash(name), "/")))
+}
+
+// path returns the name in the underlying file system
+// of the named file.
+func (b *BasePathFS) path(name string, follow bool) (string, error) {
+	rel, err := b.rel(name)
+	if err != nil {
+		return "", err
+	}
+	return joinBeneath(b.FS, b.Root, rel, follow)
+}
+
+// err replaces the underlying name in a path error
+// with the name given by the caller.
+func (b *BasePathFS) err(err error, name string) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+	return err
+}
+
+func (b *BasePathFS) Open(name string) (File, error) {
+	return b.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (b *BasePathFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	if isOS(b.FS) {
+		rel, err := b.rel(name)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok, err := openBeneath(b.Root, rel, flag, perm); ok {
+			if err != nil {
+				return nil, b.err(err, name)
+			}
+			return basePathFile{f, name}, nil
+		}
+	}
+
+	p, err := b.path(name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := b.FS.OpenFile(p, flag, perm)
+	if err != nil {
+		return nil, b.err(err, name)
+	}
+	return basePathFile{f, name}, nil
+}
+
+func (b *BasePathFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := b.path(name, true)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := b.FS.Stat(p)
+	return fi, b.err(err, name)
+}
+
+func (b *BasePathFS) Lstat(name string) (fs.FileInfo, error) {
+	p, err := b.path(name, false)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := b.FS.Lstat(p)
+	return fi, b.err(err, name)
+}
+
+func (b *BasePathFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := b.path(name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := b.FS.ReadDir(p)
+	return entries, b.err(err, name)
+}
+
+func (b *BasePathFS) Readlink(name string) (string, error) {
+	p, err := b.path(name, false)
+	if err != nil {
+		return "", err
+	}
+	target, err := b.FS.Readlink(p)
+	return target, b.err(err, name)
+}
+
+func (b *BasePathFS) Mkdir(name string, perm fs.FileMode) error {
+	p, err := b.path(name, false)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.Mkdir(p, perm), name)
+}
+
+func (b *BasePathFS) MkdirAll(name string, perm fs.FileMode) error {
+	p, err := b.path(name, true)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.MkdirAll(p, perm), name)
+}
+
+func (b *BasePathFS) Remove(name string) error {
+	p, err := b.path(name, false)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.Remove(p), name)
+}
+
+func (b *BasePathFS) RemoveAll(name string) error {
+	p, err := b.path(name, false)
+	if err != nil {
+		return err
+	}
+	if p == b.Root {
+		return NewGoFileError("cannot remove the root directory", name, ErrPermission)
+	}
+	return b.err(b.FS.RemoveAll(p), name)
+}
+
+func (b *BasePathFS) Rename(oldpath, newpath string) error {
+	oldp, err := b.path(oldpath, false)
+	if err != nil {
+		return err
+	}
+	newp, err := b.path(newpath, false)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.Rename(oldp, newp), oldpath)
+}
+
+// Symlink creates newname as a link to oldname, which
+// is resolved inside Root when the link is followed.
+func (b *BasePathFS) Symlink(oldname, newname string) error {
+	p, err := b.path(newname, false)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.Symlink(oldname, p), newname)
+}
+
+func (b *BasePathFS) Chmod(name string, mode fs.FileMode) error {
+	p, err := b.path(name, true)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.Chmod(p, mode), name)
+}
+
+func (b *BasePathFS) Chtimes(name string, atime, mtime time.Time) error {
+	p, err := b.path(name, true)
+	if err != nil {
+		return err
+	}
+	return b.err(b.FS.Chtimes(p, atime, mtime), name)
+}
+
+// basePathFile is a file opened in a BasePathFS; its
+// name is the name given to Open.
+type basePathFile struct {
+	File
+	name string
+}
+
+func (f basePathFile) Name() string { return f.name }
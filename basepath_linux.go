@@ -0,0 +1,96 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// resolveBeneath resolves rel inside root with openat2
+// and RESOLVE_BENEATH. It reports false if openat2 is
+// not available or rel does not exist, so that each
+// component is checked instead.
+func resolveBeneath(root, rel string, follow bool) (string, bool, error) {
+	rootfd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return "", true, NewGoFileError("unable to open root directory", root, err)
+	}
+	defer unix.Close(rootfd)
+
+	how := unix.OpenHow{
+		Flags:   unix.O_PATH | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	}
+	if !follow {
+		how.Flags |= unix.O_NOFOLLOW
+	}
+	fd, err := unix.Openat2(rootfd, rel, &how)
+	switch {
+	case err == nil:
+	case errors.Is(err, unix.EXDEV):
+		return "", true, NewGoFileError("path escapes root", rel, ErrPermission)
+	case errors.Is(err, unix.ELOOP):
+		return "", true, NewGoFileError("too many symbolic links", rel, err)
+	default:
+		// ENOSYS or EPERM where openat2 is not available,
+		// ENOENT for a path to be created
+		return "", false, nil
+	}
+	defer unix.Close(fd)
+
+	realRoot, err := fdPath(rootfd)
+	if err != nil {
+		return "", false, nil
+	}
+	real, err := fdPath(fd)
+	if err != nil {
+		return "", false, nil
+	}
+	p, err := filepath.Rel(realRoot, real)
+	if err != nil || escapes(p) {
+		return "", true, NewGoFileError("path escapes root", rel, ErrPermission)
+	}
+	return filepath.Join(root, p), true, nil
+}
+
+// openBeneath opens rel inside root with openat2 and
+// RESOLVE_BENEATH, with the flags and permissions of
+// os.OpenFile. The kernel resolves the path as it opens
+// the file, so links changed meanwhile cannot escape
+// root. It reports false if openat2 is not available.
+func openBeneath(root, rel string, flag int, perm fs.FileMode) (*os.File, bool, error) {
+	rootfd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, true, NewGoFileError("unable to open root directory", root, err)
+	}
+	defer unix.Close(rootfd)
+
+	how := unix.OpenHow{
+		Flags:   uint64(flag) | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	}
+	if flag&os.O_CREATE != 0 {
+		// a mode is only allowed when creating
+		how.Mode = uint64(unixMode(perm))
+	}
+	fd, err := unix.Openat2(rootfd, rel, &how)
+	switch {
+	case err == nil:
+		return os.NewFile(uintptr(fd), filepath.Join(root, rel)), true, nil
+	case errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM):
+		// openat2 is not available or is blocked
+		return nil, false, nil
+	case errors.Is(err, unix.EXDEV):
+		return nil, true, NewGoFileError("path escapes root", rel, ErrPermission)
+	}
+	return nil, true, &fs.PathError{Op: "open", Path: filepath.Join(root, rel), Err: err}
+}
+
+// fdPath returns the path of the open file fd.
+func fdPath(fd int) (string, error) {
+	return os.Readlink("/proc/self/fd/" + strconv.Itoa(fd))
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package gofile
+
+import (
+	"io/fs"
+	"os"
+)
+
+// resolveBeneath reports false; openat2 is only
+// available on Linux.
+func resolveBeneath(root, rel string, follow bool) (string, bool, error) {
+	return "", false, nil
+}
+
+// openBeneath reports false; openat2 is only available
+// on Linux.
+func openBeneath(root, rel string, flag int, perm fs.FileMode) (*os.File, bool, error) {
+	return nil, false, nil
+}
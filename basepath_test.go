@@ -0,0 +1,110 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	makeTree(t, root, "sub/", "sub/file")
+	os.Symlink("sub", filepath.Join(root, "ok"))
+	os.Symlink("../..", filepath.Join(root, "sub/up"))
+	os.Symlink("/etc", filepath.Join(root, "abs"))
+	os.Symlink("missing", filepath.Join(root, "dangling"))
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"plain", "sub/file", "sub/file", nil},
+		{"root", ".", "", nil},
+		{"cleaned", "sub/../sub/./file", "sub/file", nil},
+		{"new file", "sub/new", "sub/new", nil},
+		{"new path", "missing/a/b", "missing/a/b", nil},
+		{"link", "ok/file", "sub/file", nil},
+		{"link to new file", "ok/new", "sub/new", nil},
+		{"dangling link", "dangling", "missing", nil},
+		{"dot dot", "../root/sub", "", ErrPermission},
+		{"dot dot inside", "sub/../../x", "", ErrPermission},
+		{"absolute", "/etc/passwd", "", ErrPermission},
+		{"link up", "sub/up/x", "", ErrPermission},
+		{"absolute link", "abs/passwd", "", ErrPermission},
+		{"NUL", "sub/\x00", "", ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SafeJoin(root, tt.in)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("SafeJoin() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != filepath.Join(root, tt.want) {
+				t.Errorf("SafeJoin() = %q, want %q", got, filepath.Join(root, tt.want))
+			}
+
+			// the component checks must agree with openat2
+			rel, err := cleanRelative(tt.in)
+			if err != nil {
+				return
+			}
+			got, err = resolveIn(OSFS{}, root, rel, true)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("resolveIn() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != filepath.Join(root, tt.want) {
+				t.Errorf("resolveIn() = %q, want %q", got, filepath.Join(root, tt.want))
+			}
+		})
+	}
+}
+
+func TestBasePathFS(t *testing.T) {
+	mem := NewMemFS()
+	mem.MkdirAll("/base", DirMode)
+	testFileSystem(t, NewBasePathFS(mem, "/base"), "/")
+
+	testFileSystem(t, NewBasePathFS(nil, t.TempDir()), "/")
+
+	fsys := NewBasePathFS(nil, t.TempDir())
+	if err := fsys.Symlink("../..", "up"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Open("up/etc/passwd"); !errors.Is(err, ErrPermission) {
+		t.Errorf("Open() through link error = %v, want ErrPermission", err)
+	}
+	if err := WriteFileFS(fsys, "/../../file", []byte("data"), NormalMode, false); !errors.Is(err, ErrPermission) {
+		t.Errorf("WriteFileFS(, false) above root error = %v, want ErrPermission", err)
+	}
+	if _, err := fsys.Stat("sub/../../file"); !errors.Is(err, ErrPermission) {
+		t.Errorf("Stat() above root error = %v, want ErrPermission", err)
+	}
+	if err := WriteFileFS(fsys, "/file", []byte("data"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(fsys.Root, "file")); err != nil {
+		t.Errorf("file not created inside root: %v", err)
+	}
+
+	// a dangling link out of root must not be created
+	// through, whether or not openat2 is used
+	outside := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "created"), filepath.Join(fsys.Root, "out")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateFileFS(fsys, "out", NormalMode, false); !errors.Is(err, ErrPermission) {
+		t.Errorf("CreateFileFS() through link error = %v, want ErrPermission", err)
+	}
+	if f, ok, err := openBeneath(fsys.Root, "out", os.O_RDWR|os.O_CREATE, NormalMode); ok && !errors.Is(err, ErrPermission) {
+		if f != nil {
+			f.Close()
+		}
+		t.Errorf("openBeneath() through link error = %v, want ErrPermission", err)
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "created")); !errors.Is(err, ErrNotExist) {
+		t.Errorf("file created outside root: %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package gofile
+
+import (
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ModeSpec is a file mode change, parsed by ParseMode
+// from the octal or symbolic forms accepted by chmod(1).
+type ModeSpec struct {
+	octal   bool
+	digits  int
+	mode    uint32 // the mode set, in octal form
+	clauses []modeClause
+}
+
+// modeClause is a symbolic clause such as "go-w" or
+// "g=u"; each operation applies to the same users.
+type modeClause struct {
+	who uint32 // bits of the users affected
+	ops []modeOp
+}
+
+type modeOp struct {
+	op   byte   // '+', '-' or '='
+	perm uint32 // bits set by r, w, x, s and t
+	x    bool   // X: execute if a directory or executable
+	copy byte   // 'u', 'g' or 'o' to copy those bits, if not zero
+}
+
+// Bits of the users in chmod(1) order; the special bits
+// go with the users they apply to.
+const (
+	whoUser  uint32 = 04700
+	whoGroup uint32 = 02070
+	whoOther uint32 = 01007
+	whoAll          = whoUser | whoGroup | whoOther
+)
+
+// ParseMode parses a mode as accepted by chmod(1):
+// either an octal number of up to five digits, or a
+// comma separated list of symbolic clauses such as
+// "u+rwX,go-w" or "g=u". A clause without users
+// applies to all of them; the umask is not used.
+func ParseMode(spec string) (ModeSpec, error) {
+	invalid := NewGoFileError("invalid file mode", spec, ErrInvalid)
+	if spec == "" {
+		return ModeSpec{}, invalid
+	}
+
+	if spec[0] >= '0' && spec[0] <= '7' {
+		if len(spec) > 5 {
+			return ModeSpec{}, invalid
+		}
+		m, err := strconv.ParseUint(spec, 8, 32)
+		if err != nil || m > 07777 {
+			return ModeSpec{}, invalid
+		}
+		return ModeSpec{octal: true, digits: len(spec), mode: uint32(m)}, nil
+	}
+
+	var s ModeSpec
+	for _, text := range strings.Split(spec, ",") {
+		c, ok := parseClause(text)
+		if !ok {
+			return ModeSpec{}, invalid
+		}
+		s.clauses = append(s.clauses, c)
+	}
+	return s, nil
+}
+
+func parseClause(text string) (modeClause, bool) {
+	var c modeClause
+	users := [...]uint32{whoUser, whoGroup, whoOther, whoAll}
+	i := 0
+	for ; i < len(text); i++ {
+		u := strings.IndexByte("ugoa", text[i])
+		if u < 0 {
+			break
+		}
+		c.who |= users[u]
+	}
+	if c.who == 0 {
+		c.who = whoAll
+	}
+	if i == len(text) {
+		return c, false // no operation
+	}
+
+	for i < len(text) {
+		op := modeOp{op: text[i]}
+		if op.op != '+' && op.op != '-' && op.op != '=' {
+			return c, false
+		}
+		i++
+
+		if i < len(text) && strings.IndexByte("ugo", text[i]) >= 0 {
+			op.copy = text[i]
+			i++
+		} else {
+			for ; i < len(text) && strings.IndexByte("rwxXst", text[i]) >= 0; i++ {
+				switch text[i] {
+				case 'r':
+					op.perm |= 0444
+				case 'w':
+					op.perm |= 0222
+				case 'x':
+					op.perm |= 0111
+				case 'X':
+					op.x = true
+				case 's':
+					op.perm |= 06000
+				case 't':
+					op.perm |= 01000
+				}
+			}
+		}
+		c.ops = append(c.ops, op)
+	}
+	return c, true
+}
+
+// Apply returns mode changed by s. The type bits of
+// mode are kept, and X depends on whether mode is a
+// directory. As in GNU chmod, the set-ID bits of a
+// directory are only cleared by an octal mode of five
+// digits, such as 00755, or by a clause that lists
+// them, such as g-s.
+func (s ModeSpec) Apply(mode fs.FileMode) fs.FileMode {
+	m := unixMode(mode)
+	if s.octal {
+		keep := uint32(0)
+		if mode.IsDir() && s.digits < 5 {
+			keep = m & 06000
+		}
+		return mode.Type() | fsMode(s.mode|keep)
+	}
+
+	for _, c := range s.clauses {
+		for _, op := range c.ops {
+			bits := op.perm
+			if op.x && (mode.IsDir() || m&0111 != 0) {
+				bits |= 0111
+			}
+			switch op.copy {
+			case 'u':
+				bits = (m >> 6 & 7) * 0111
+			case 'g':
+				bits = (m >> 3 & 7) * 0111
+			case 'o':
+				bits = (m & 7) * 0111
+			}
+			bits &= c.who
+
+			switch op.op {
+			case '+':
+				m |= bits
+			case '-':
+				m &^= bits
+			case '=':
+				clear := c.who
+				if mode.IsDir() && op.perm&06000 == 0 {
+					clear &^= 06000
+				}
+				m = m&^clear | bits
+			}
+		}
+	}
+	return mode.Type() | fsMode(m)
+}
+
+// unixMode returns the permission and special bits of
+// mode in their octal form.
+func unixMode(mode fs.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		m |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		m |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		m |= 01000
+	}
+	return m
+}
+
+// fsMode returns the fs.FileMode of the octal mode m.
+func fsMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0777)
+	if m&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// ChmodSpec changes the mode of the named file as
+// described by spec, in the form accepted by ParseMode.
+func ChmodSpec(name, spec string) error {
+	s, err := ParseMode(spec)
+	if err != nil {
+		return err
+	}
+	fi, err := OSFS{}.Stat(name)
+	if err != nil {
+		return NewGoFileError("unable to stat file", name, err)
+	}
+	if err := (OSFS{}).Chmod(name, s.Apply(fi.Mode())); err != nil {
+		return NewGoFileError("unable to change file mode", name, err)
+	}
+	return nil
+}
+
+// ChmodTree changes the mode of root and the files and
+// directories below it as described by spec, as with
+// chmod -R, using the default Walker.
+func ChmodTree(root, spec string) error {
+	return (&Walker{}).ChmodTree(root, spec)
+}
+
+// ChmodTree changes the mode of root and the files and
+// directories below it as described by spec, in the
+// form accepted by ParseMode. Symbolic links are not
+// changed or followed.
+//
+// Files are changed as they are visited. Directories
+// are made readable and searchable by their owner
+// while their contents are changed, and are given
+// their new modes, deepest first, at the end.
+func (w *Walker) ChmodTree(root, spec string) error {
+	s, err := ParseMode(spec)
+	if err != nil {
+		return err
+	}
+	fsys := w.fs()
+
+	var mu sync.Mutex
+	dirs := make(map[string]fs.FileMode) // directory -> original mode
+	widened := make(map[string]bool)
+
+	// record notes the mode of dir before it is changed;
+	// the caller holds mu
+	record := func(dir string) (fs.FileMode, error) {
+		if mode, ok := dirs[dir]; ok {
+			return mode, nil
+		}
+		fi, err := fsys.Lstat(dir)
+		if err != nil {
+			return 0, NewGoFileError("unable to stat directory", dir, err)
+		}
+		dirs[dir] = fi.Mode()
+		return fi.Mode(), nil
+	}
+
+	// widen makes dir readable and searchable by its
+	// owner; the caller holds mu
+	widen := func(dir string) error {
+		if widened[dir] {
+			return nil
+		}
+		widened[dir] = true
+		mode, err := record(dir)
+		if err != nil {
+			return err
+		}
+		if mode.Perm()&0500 != 0500 {
+			if err := fsys.Chmod(dir, mode|0500); err != nil {
+				return NewGoFileError("unable to make directory readable", dir, err)
+			}
+		}
+		return nil
+	}
+
+	walker := *w
+	walker.ReadDir = func(dir string) ([]fs.DirEntry, error) {
+		mu.Lock()
+		err := widen(dir)
+		mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := w.readDir(dir)
+		if err != nil {
+			return nil, NewGoFileError("unable to read directory", dir, err)
+		}
+		return entries, nil
+	}
+
+	err = walker.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d == nil {
+				return NewGoFileError("unable to stat file", path, err)
+			}
+			// errors reading a directory are wrapped above
+			return err
+		}
+		if d.IsDir() {
+			// directories not read, as at MaxDepth, are
+			// changed at the end with the others
+			mu.Lock()
+			_, err := record(path)
+			mu.Unlock()
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return NewGoFileError("unable to stat file", path, err)
+		}
+		if mode := s.Apply(fi.Mode()); mode != fi.Mode() {
+			if err := fsys.Chmod(path, mode); err != nil {
+				return NewGoFileError("unable to change file mode", path, err)
+			}
+		}
+		return nil
+	})
+
+	// descendants sort after their directories
+	paths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		paths = append(paths, dir)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	for _, dir := range paths {
+		if cerr := fsys.Chmod(dir, s.Apply(dirs[dir])); cerr != nil && err == nil {
+			err = NewGoFileError("unable to change directory mode", dir, cerr)
+		}
+	}
+	return err
+}
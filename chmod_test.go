@@ -0,0 +1,161 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		spec string
+		mode fs.FileMode
+		want fs.FileMode
+	}{
+		{"644", 0777, 0644},
+		{"4755", 0644, 0755 | fs.ModeSetuid},
+		{"755", fs.ModeDir | fs.ModeSetgid | 0700, fs.ModeDir | fs.ModeSetgid | 0755},
+		{"0755", fs.ModeDir | fs.ModeSetgid | 0700, fs.ModeDir | fs.ModeSetgid | 0755},
+		{"00755", fs.ModeDir | fs.ModeSetgid | 0700, fs.ModeDir | 0755},
+		{"0755", fs.ModeSetgid | 0700, 0755},
+		{"g=rx", fs.ModeDir | fs.ModeSetgid | 0755, fs.ModeDir | fs.ModeSetgid | 0755},
+		{"g=u", fs.ModeDir | fs.ModeSetgid | 0750, fs.ModeDir | fs.ModeSetgid | 0770},
+		{"g=rxs", fs.ModeDir | 0755, fs.ModeDir | fs.ModeSetgid | 0755},
+		{"g-s", fs.ModeDir | fs.ModeSetgid | 0755, fs.ModeDir | 0755},
+		{"g=rx", fs.ModeSetgid | 0755, 0755},
+		{"u+x", 0644, 0744},
+		{"+x", 0644, 0755},
+		{"a-w", 0666, 0444},
+		{"go-w", 0666, 0644},
+		{"u=rw,go=r", 0777, 0644},
+		{"u+rwX,go-w", 0600, 0600},
+		{"u+rwX,go-w", 0670, 0750},
+		{"u+rwX,go-w", fs.ModeDir | 0020, fs.ModeDir | 0700},
+		{"a+X", 0644, 0644},
+		{"a+X", 0744, 0755},
+		{"g=u", 0640, 0660},
+		{"o=g", 0751, 0755},
+		{"u=", 0755, 0055},
+		{"u+s,g+s", 0755, 0755 | fs.ModeSetuid | fs.ModeSetgid},
+		{"o+s", 0755, 0755},
+		{"+t", fs.ModeDir | 0777, fs.ModeDir | fs.ModeSticky | 0777},
+		{"u+r-w+x", 0200, 0500},
+		{"ug=rw,o=", fs.ModeSymlink | 0777, fs.ModeSymlink | 0660},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseMode(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Apply(tt.mode); got != tt.want {
+				t.Errorf("Apply(%v) = %v, want %v", tt.mode, got, tt.want)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "8", "077777", "17777", "u", "u+q", "x+r", "u+r,", "+rg"} {
+		if _, err := ParseMode(spec); !errors.Is(err, ErrInvalid) {
+			t.Errorf("ParseMode(%q) error = %v, want ErrInvalid", spec, err)
+		}
+	}
+}
+
+func TestChmodTree(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "a/", "a/b/", "a/b/file", "a/script", "top")
+	os.Chmod(filepath.Join(dir, "a/script"), 0775)
+	os.Chmod(filepath.Join(dir, "top"), 0666)
+	os.Symlink("top", filepath.Join(dir, "link"))
+	os.Chmod(filepath.Join(dir, "a/b"), 0)
+	defer os.Chmod(filepath.Join(dir, "a/b"), DirMode)
+
+	if err := ChmodTree(dir, "u+rwX,go-w"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]fs.FileMode{
+		".":        fs.ModeDir | 0755,
+		"a":        fs.ModeDir | 0755,
+		"a/b":      fs.ModeDir | 0700,
+		"a/b/file": 0644,
+		"a/script": 0755,
+		"top":      0644,
+	}
+	for name, mode := range want {
+		fi, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode() != mode {
+			t.Errorf("mode of %s = %v, want %v", name, fi.Mode(), mode)
+		}
+	}
+
+	if err := ChmodTree(dir, "go="); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(filepath.Join(dir, "a")); fi.Mode() != fs.ModeDir|0700 {
+		t.Errorf("mode of a = %v, want %v", fi.Mode(), fs.ModeDir|0700)
+	}
+	if err := ChmodTree(dir, "u+q"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("ChmodTree() error = %v, want ErrInvalid", err)
+	}
+}
+
+// permFS denies reading directories that are not
+// readable and searchable by their owner, as the OS
+// does for users other than root.
+type permFS struct{ FileSystem }
+
+func (p permFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fi, err := p.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode().Perm()&0500 != 0500 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EACCES}
+	}
+	return p.FileSystem.ReadDir(name)
+}
+
+func TestChmodTree_unreadable(t *testing.T) {
+	mem := NewMemFS()
+	mem.MkdirAll("/a/b", DirMode)
+	if err := WriteFileFS(mem, "/a/b/file", nil, 0600, false); err != nil {
+		t.Fatal(err)
+	}
+	mem.Chmod("/a/b", 0)
+	mem.Chmod("/a", 0200)
+
+	w := &Walker{FS: permFS{mem}}
+	if err := w.ChmodTree("/a", "go+r"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]fs.FileMode{
+		"/a":        fs.ModeDir | 0244,
+		"/a/b":      fs.ModeDir | 0044,
+		"/a/b/file": 0644,
+	}
+	for name, mode := range want {
+		if fi, err := mem.Lstat(name); err != nil || fi.Mode() != mode {
+			t.Errorf("mode of %s = %v, %v; want %v", name, fi.Mode(), err, mode)
+		}
+	}
+
+	// a directory that cannot be read is reported once
+	fsys := NewFaultFS(mem, Fault{Op: FaultReadDir, Pattern: "b", Err: syscall.EIO})
+	err := (&Walker{FS: fsys}).ChmodTree("/a", "u+rwx")
+	var wraps int
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if _, ok := e.(*GoFileError); ok {
+			wraps++
+		}
+	}
+	if !errors.Is(err, syscall.EIO) || wraps != 1 {
+		t.Errorf("ChmodTree() error = %v, want EIO wrapped once", err)
+	}
+}
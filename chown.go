@@ -0,0 +1,87 @@
+package gofile
+
+import (
+	"io/fs"
+	"os"
+)
+
+// Chown changes the owner of the named file, following
+// symbolic links, to owner, in the form accepted by
+// ParseOwner, such as "app:app".
+func Chown(name, owner string) error {
+	return chown(os.Chown, name, owner)
+}
+
+// Lchown is Chown for the named file itself; if it is
+// a symbolic link, the owner of the link is changed.
+func Lchown(name, owner string) error {
+	return chown(os.Lchown, name, owner)
+}
+
+func chown(fn func(string, int, int) error, name, owner string) error {
+	uid, gid, err := ParseOwner(owner)
+	if err != nil {
+		return err
+	}
+	if err := fn(name, uid, gid); err != nil {
+		return NewGoFileError("unable to change owner", name, err)
+	}
+	return nil
+}
+
+// ChownOptions are the options of ChownTree.
+type ChownOptions struct {
+	// From, if set, limits the change to files owned by
+	// the user and group given, in the form accepted by
+	// ParseOwner, as with the --from option of chown(1).
+	// A user or group left out matches any.
+	From string
+
+	// SkipSymlinks leaves symbolic links unchanged.
+	// Otherwise the owner of the link itself is changed.
+	SkipSymlinks bool
+}
+
+// ChownTree changes the owner of root and the files and
+// directories below it to owner, as with chown -R.
+// Symbolic links are not followed.
+func ChownTree(root, owner string, opts ChownOptions) error {
+	uid, gid, err := ParseOwner(owner)
+	if err != nil {
+		return err
+	}
+	fromUID, fromGID := -1, -1
+	if opts.From != "" {
+		if fromUID, fromGID, err = ParseOwner(opts.From); err != nil {
+			return err
+		}
+	}
+
+	return (&Walker{}).Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return NewGoFileError("unable to read directory", path, err)
+		}
+		if opts.SkipSymlinks && d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		if fromUID >= 0 || fromGID >= 0 {
+			fi, err := d.Info()
+			if err != nil {
+				return NewGoFileError("unable to stat file", path, err)
+			}
+			st, ok := statSys(fi)
+			if !ok {
+				return NewGoFileError("file owner not available", path, ErrNotImplemented)
+			}
+			if fromUID >= 0 && int(st.uid) != fromUID || fromGID >= 0 && int(st.gid) != fromGID {
+				return nil
+			}
+		}
+
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return NewGoFileError("unable to change owner", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package gofile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChownTree(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing owners requires root")
+	}
+	withIDFiles(t, testPasswd, testGroup)
+
+	dir := t.TempDir()
+	makeTree(t, dir, "a/", "a/file", "other")
+	os.Symlink("other", filepath.Join(dir, "link"))
+	os.Lchown(filepath.Join(dir, "other"), 42, 42)
+
+	owner := func(name string) [2]uint32 {
+		t.Helper()
+		fi, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, _ := statSys(fi)
+		return [2]uint32{st.uid, st.gid}
+	}
+
+	// only files owned by root change
+	if err := ChownTree(dir, "app:deploy", ChownOptions{From: "root", SkipSymlinks: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]uint32{
+		".":      {1001, 1003},
+		"a":      {1001, 1003},
+		"a/file": {1001, 1003},
+		"other":  {42, 42},
+		"link":   {0, 0},
+	}
+	for name, w := range want {
+		if got := owner(name); got != w {
+			t.Errorf("owner of %s = %v, want %v", name, got, w)
+		}
+	}
+
+	if err := ChownTree(dir, ":root", ChownOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := owner("link"); got != [2]uint32{0, 0} {
+		t.Errorf("owner of link = %v, want [0 0]", got)
+	}
+	if got := owner("other"); got != [2]uint32{42, 0} {
+		t.Errorf("owner of other = %v, want [42 0]", got)
+	}
+
+	if err := Chown(filepath.Join(dir, "link"), "app:"); err != nil {
+		t.Fatal(err)
+	}
+	if got := owner("other"); got != [2]uint32{1001, 1002} {
+		t.Errorf("owner of link target = %v, want [1001 1002]", got)
+	}
+	if err := Lchown(filepath.Join(dir, "link"), "42"); err != nil {
+		t.Fatal(err)
+	}
+	if got := owner("link"); got != [2]uint32{42, 0} {
+		t.Errorf("owner of link = %v, want [42 0]", got)
+	}
+}
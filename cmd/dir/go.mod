@@ -3,19 +3,15 @@ module github.com/skeptycal/goutil/gofile/cmd/dir
 go 1.18
 
 require (
-	github.com/fatih/color v1.13.0
 	github.com/sirupsen/logrus v1.8.1
-	github.com/skeptycal/ansi v0.4.2
-	github.com/skeptycal/basicfile v0.0.0-20220330084507-92bbfe7b4dd8
-	github.com/skeptycal/gofile v0.5.0
+	github.com/skeptycal/goutil/gofile v0.0.0
 )
 
 require (
-	github.com/mattn/go-colorable v0.1.9 // indirect
-	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/skeptycal/basicfile v0.0.0-20220405190439-d5f7ae669feb // indirect
 	github.com/skeptycal/errorlogger v0.5.0 // indirect
-	github.com/stretchr/testify v1.7.1 // indirect
-	golang.org/x/sys v0.0.0-20220330033206-e17cdc41300f // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	golang.org/x/sys v0.0.0-20220405052023-b1e9470b6e64 // indirect
 )
+
+replace github.com/skeptycal/goutil/gofile => ../..
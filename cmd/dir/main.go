@@ -3,11 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
-	"time"
 
-	"github.com/fatih/color"
 	log "github.com/sirupsen/logrus"
-	"github.com/skeptycal/basicfile"
+	"github.com/skeptycal/goutil/gofile"
 )
 
 // func Dir(path string) string {
@@ -24,15 +22,11 @@ import (
 
 func main() {
 
-	green := color.New(color.FgHiGreen)
-	blue := color.New(color.FgHiBlue)
-	color := color.New(color.Bold, color.FgHiWhite, color.BgBlack)
-
 	log.Info("log started...")
 
 	var testpath string
 
-	if len(os.Args) > 2 {
+	if len(os.Args) > 1 {
 		testpath = os.Args[1]
 	} else {
 		var err error
@@ -44,49 +38,19 @@ func main() {
 
 	log.Info("testpath: ", testpath)
 
-	// d, err := gofile.NewDIR(testpath)
-	d, err := basicfile.NewBasicFile(testpath)
+	d, err := gofile.NewDIR(testpath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Print(color)
-
-	root := d.FileOps().Abs()
+	log.Info("d.Path(): ", d.Path())
 
-	log.Info("d.Name(): ", root)
-
-	rdList, err := os.ReadDir(root)
-	if err != nil {
-		log.Fatalf("os.ReadDir error: %v", err)
-	}
-
-	fmt.Printf("directory of %s\n", d.Name())
-	for _, f := range rdList {
-		fi, err := f.Info()
-		if err != nil {
-			log.Error(err)
-			continue
-		}
-		if fi.IsDir() {
-			color = blue
-		} else {
-			color = green
-		}
-		// fmt.Printf("%s%s\n", color, fi.Name())
-		// fmt.Printf("%s\n", f.Base())
-
-		color.Printf("%v %7d %v %s\n", fi.Mode(), fi.Size(), fi.ModTime().Format(time.Stamp), fi.Name())
-		// fmt.Printf("%s\n", f.Base())
+	fmt.Printf("directory of %s\n", d.Path())
 
+	// names are colored from LS_COLORS when stdout is a terminal
+	if _, err := d.WriteTo(os.Stdout); err != nil {
+		log.Fatal(err)
 	}
 
 	fmt.Println("")
-
-	// file := list[0].FileInfo()
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
-
-	// fmt.Printf("first file: %v\n", file.Mode())
 }
@@ -0,0 +1,598 @@
+package gofile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompareStatus is the result of comparing a path in
+// two directory trees.
+type CompareStatus int
+
+const (
+	OnlyInA CompareStatus = iota + 1
+	OnlyInB
+	Identical
+	Different
+)
+
+var compareNames = map[CompareStatus]string{
+	1: "OnlyInA",
+	2: "OnlyInB",
+	3: "Identical",
+	4: "Different",
+}
+
+func (s CompareStatus) String() string {
+	return compareNames[s]
+}
+
+// CompareResult is the comparison of one path found in
+// either or both trees given to CompareDirs.
+type CompareResult struct {
+	Path   string // relative to the roots, '/' separated
+	Status CompareStatus
+	Reason string // why the files differ: "type", "size", "mode", "mtime", "target" or "content"
+	A, B   fs.FileInfo
+}
+
+// CompareOptions are the options for CompareDirs.
+type CompareOptions struct {
+	// Content compares the contents of regular files
+	// of the same size. Otherwise files of the same
+	// size are identical if their modification times
+	// are equal.
+	Content bool
+
+	// Perm reports files with different permission
+	// bits as different.
+	Perm bool
+
+	// Exclude skips paths, relative to the roots, that
+	// match any of the patterns, using the syntax of
+	// Match.
+	Exclude []string
+
+	// FS holds both trees, such as a MemFS in tests.
+	// The host file system is used if it is nil.
+	FS FileSystem
+}
+
+// CompareDirs walks the trees rooted at a and b in
+// lockstep and compares the files found at each path,
+// as with 'diff -rq'. Paths are reported in the order
+// of filepath.WalkDir. A directory found in only one
+// tree is reported but not descended into. Directories
+// found in both trees are descended into but not
+// reported. Symbolic links are not followed; links are
+// identical if their targets are.
+func CompareDirs(a, b string, opts CompareOptions) ([]CompareResult, error) {
+	for _, pattern := range opts.Exclude {
+		if _, err := Match(pattern, ""); err != nil {
+			return nil, NewGoFileError("invalid exclude pattern", pattern, err)
+		}
+	}
+	opts.FS = fsOrOS(opts.FS)
+	var results []CompareResult
+	err := compareDir(a, b, "", opts, &results)
+	return results, err
+}
+
+// compareDir compares the directories a/rel and b/rel,
+// appending the results.
+func compareDir(a, b, rel string, opts CompareOptions, results *[]CompareResult) error {
+	dirA, dirB := filepath.Join(a, rel), filepath.Join(b, rel)
+	entriesA, err := opts.FS.ReadDir(dirA)
+	if err != nil {
+		return NewGoFileError("unable to read directory for comparison", dirA, err)
+	}
+	entriesB, err := opts.FS.ReadDir(dirB)
+	if err != nil {
+		return NewGoFileError("unable to read directory for comparison", dirB, err)
+	}
+
+	// both lists are sorted by name
+	for i, j := 0, 0; i < len(entriesA) || j < len(entriesB); {
+		var ea, eb fs.DirEntry
+		switch {
+		case j == len(entriesB) || i < len(entriesA) && entriesA[i].Name() < entriesB[j].Name():
+			ea = entriesA[i]
+			i++
+		case i == len(entriesA) || entriesB[j].Name() < entriesA[i].Name():
+			eb = entriesB[j]
+			j++
+		default:
+			ea, eb = entriesA[i], entriesB[j]
+			i++
+			j++
+		}
+
+		name := ea
+		if name == nil {
+			name = eb
+		}
+		path := filepath.Join(rel, name.Name())
+		if excluded(opts.Exclude, path) {
+			continue
+		}
+
+		r := CompareResult{Path: filepath.ToSlash(path)}
+		if ea != nil {
+			if r.A, err = ea.Info(); err != nil {
+				return NewGoFileError("unable to stat file for comparison", filepath.Join(a, path), err)
+			}
+		}
+		if eb != nil {
+			if r.B, err = eb.Info(); err != nil {
+				return NewGoFileError("unable to stat file for comparison", filepath.Join(b, path), err)
+			}
+		}
+
+		switch {
+		case eb == nil:
+			r.Status = OnlyInA
+		case ea == nil:
+			r.Status = OnlyInB
+		case ea.IsDir() && eb.IsDir():
+			if err := compareDir(a, b, path, opts, results); err != nil {
+				return err
+			}
+			continue
+		default:
+			r.Reason, err = compareFiles(filepath.Join(a, path), filepath.Join(b, path), r.A, r.B, opts)
+			if err != nil {
+				return err
+			}
+			r.Status = Identical
+			if r.Reason != "" {
+				r.Status = Different
+			}
+		}
+		*results = append(*results, r)
+	}
+	return nil
+}
+
+// excluded reports whether the relative path matches
+// any of the patterns.
+func excluded(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := Match(pattern, filepath.ToSlash(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compareFiles returns the reason the files a and b
+// differ, or "" if they are identical.
+func compareFiles(a, b string, fa, fb fs.FileInfo, opts CompareOptions) (string, error) {
+	switch {
+	case fa.Mode().Type() != fb.Mode().Type():
+		return "type", nil
+	case opts.Perm && fa.Mode().Perm() != fb.Mode().Perm():
+		return "mode", nil
+	case fa.Mode()&fs.ModeSymlink != 0:
+		ta, err := opts.FS.Readlink(a)
+		if err != nil {
+			return "", NewGoFileError("unable to read link for comparison", a, err)
+		}
+		tb, err := opts.FS.Readlink(b)
+		if err != nil {
+			return "", NewGoFileError("unable to read link for comparison", b, err)
+		}
+		if ta != tb {
+			return "target", nil
+		}
+		return "", nil
+	case !fa.Mode().IsRegular():
+		return "", nil
+	case fa.Size() != fb.Size():
+		return "size", nil
+	case !opts.Content:
+		if !fa.ModTime().Equal(fb.ModTime()) {
+			return "mtime", nil
+		}
+		return "", nil
+	}
+
+	same, err := sameContents(opts.FS, a, b)
+	if err != nil || same {
+		return "", err
+	}
+	return "content", nil
+}
+
+// sameContents reports whether the named files in
+// fsys have the same contents.
+func sameContents(fsys FileSystem, a, b string) (bool, error) {
+	fa, err := fsys.Open(a)
+	if err != nil {
+		return false, NewGoFileError("unable to open file for comparison", a, err)
+	}
+	defer fa.Close()
+	fb, err := fsys.Open(b)
+	if err != nil {
+		return false, NewGoFileError("unable to open file for comparison", b, err)
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, DefaultBufSize)
+	bufB := make([]byte, DefaultBufSize)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		switch {
+		case errA != nil && !endA:
+			return false, NewGoFileError("unable to read file for comparison", a, errA)
+		case errB != nil && !endB:
+			return false, NewGoFileError("unable to read file for comparison", b, errB)
+		case endA || endB:
+			return endA && endB, nil
+		}
+	}
+}
+
+// WriteDirDiff writes the results of CompareDirs for
+// the trees a and b to w, as with 'diff -ru': a note
+// for each path found in only one tree and a unified
+// diff, with context lines of context, for each pair
+// of regular files that differ. Identical files are
+// not written.
+func WriteDirDiff(w io.Writer, a, b string, results []CompareResult, context int) error {
+	return WriteDirDiffFS(nil, w, a, b, results, context)
+}
+
+// WriteDirDiffFS is WriteDirDiff for trees in fsys,
+// which should be the FS given to CompareDirs. If fsys
+// is nil, the host file system is used.
+func WriteDirDiffFS(fsys FileSystem, w io.Writer, a, b string, results []CompareResult, context int) error {
+	for _, r := range results {
+		pa := filepath.Join(a, filepath.FromSlash(r.Path))
+		pb := filepath.Join(b, filepath.FromSlash(r.Path))
+
+		var err error
+		switch {
+		case r.Status == OnlyInA:
+			_, err = fmt.Fprintf(w, "Only in %s: %s\n", filepath.Dir(pa), filepath.Base(pa))
+		case r.Status == OnlyInB:
+			_, err = fmt.Fprintf(w, "Only in %s: %s\n", filepath.Dir(pb), filepath.Base(pb))
+		case r.Status == Identical:
+		case r.A.Mode().IsRegular() && r.B.Mode().IsRegular() && r.Reason != "mode":
+			// files with different times may have the same contents
+			var buf bytes.Buffer
+			if err = UnifiedDiffFS(fsys, &buf, pa, pb, context); err == nil && buf.Len() > 0 {
+				if _, err = fmt.Fprintf(w, "diff -u %s %s\n", pa, pb); err == nil {
+					_, err = buf.WriteTo(w)
+				}
+			}
+		default:
+			_, err = fmt.Fprintf(w, "Files %s and %s differ (%s)\n", pa, pb, r.Reason)
+		}
+		if err != nil {
+			return NewGoFileError("unable to write directory diff", r.Path, err)
+		}
+	}
+	return nil
+}
+
+// diffTimeFormat is the time format of unified diff
+// file headers.
+const diffTimeFormat = "2006-01-02 15:04:05.000000000 -0700"
+
+// UnifiedDiff writes the differences between the named
+// text files a and b to w in unified diff format, with
+// context lines of context around each change. Nothing
+// is written if the files are identical. If either file
+// appears to be binary, only a note that the files
+// differ is written.
+func UnifiedDiff(w io.Writer, a, b string, context int) error {
+	return UnifiedDiffFS(nil, w, a, b, context)
+}
+
+// UnifiedDiffFS is UnifiedDiff for files in fsys. If
+// fsys is nil, the host file system is used.
+func UnifiedDiffFS(fsys FileSystem, w io.Writer, a, b string, context int) error {
+	fsys = fsOrOS(fsys)
+	textA, fa, err := readDiffFile(fsys, a)
+	if err != nil {
+		return err
+	}
+	textB, fb, err := readDiffFile(fsys, b)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(textA, textB) {
+		return nil
+	}
+	if isBinary(textA) || isBinary(textB) {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", a, b)
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\t%s\n", a, fa.ModTime().Format(diffTimeFormat))
+	fmt.Fprintf(bw, "+++ %s\t%s\n", b, fb.ModTime().Format(diffTimeFormat))
+	writeHunks(bw, diffLines(splitLines(textA), splitLines(textB)), context)
+	return bw.Flush()
+}
+
+func readDiffFile(fsys FileSystem, name string) ([]byte, fs.FileInfo, error) {
+	fi, err := fsys.Stat(name)
+	if err != nil {
+		return nil, nil, NewGoFileError("unable to stat file for diff", name, err)
+	}
+	b, err := ReadFileFS(fsys, name)
+	if err != nil {
+		return nil, nil, NewGoFileError("unable to read file for diff", name, err)
+	}
+	return b, fi, nil
+}
+
+// isBinary reports whether b appears to be binary,
+// using the heuristic of git and diff: a NUL byte in
+// the first 8000 bytes.
+func isBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+// splitLines splits b after each newline. The last
+// line has no newline if b does not end with one.
+func splitLines(b []byte) []string {
+	var lines []string
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+		lines = append(lines, string(b[:i]))
+		b = b[i:]
+	}
+	return lines
+}
+
+// diffOp is one line of an edit script: ' ' for a line
+// in both files, '-' for a line deleted from the first
+// and '+' for a line inserted from the second.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns a shortest edit script from a to
+// b, using the linear space variant of the algorithm of
+// Myers, "An O(ND) Difference Algorithm and Its
+// Variations" (1986): the script is split where the
+// searches from both ends meet, and the parts on either
+// side are diffed in turn, so that memory grows with
+// the number of lines rather than with the number of
+// lines times the number of differences.
+func diffLines(a, b []string) []diffOp {
+	ops := appendDiff(make([]diffOp, 0, len(a)+len(b)), a, b)
+
+	// as in diff, deletions come before the insertions
+	// that replace them
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(ops) && ops[j].kind != ' ' {
+			j++
+		}
+		sort.SliceStable(ops[i:j], func(p, q int) bool { return ops[i+p].kind == '-' && ops[i+q].kind == '+' })
+		i = j
+	}
+	return ops
+}
+
+// appendDiff appends the edit script from a to b to
+// ops.
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	x, y, ok := diffSplit(a, b)
+	if ok {
+		ops = appendDiff(ops, a[:x], b[:y])
+		ops = appendDiff(ops, a[x:], b[y:])
+	} else {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+	}
+	for _, line := range common {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffSplit searches for a shortest edit script from a
+// to b from both ends at once, and returns the point
+// (x, y) where the searches meet, so that the script is
+// that of a[:x] to b[:y] followed by that of a[x:] to
+// b[y:]. It reports false if a or b is empty, or if
+// they have no line in common, when the script deletes
+// all of a and inserts all of b.
+//
+// The first and last lines of a and b must differ, as
+// they do once appendDiff removes the common prefix and
+// suffix; the point returned then splits the search.
+func diffSplit(a, b []string) (x, y int, ok bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+
+	// vf[offset+k] is the furthest x reached on the
+	// diagonal x-y = k from the start, and vb[offset+k]
+	// that reached from the end, with x counted from the
+	// end of a; -1 is unreached
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	delta := n - m
+	front := delta%2 != 0 // the searches meet going forward
+	var kfStart, kfEnd, kbStart, kbEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + kfStart; k <= d-kfEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || k != d && vf[i-1] < vf[i+1] {
+				x = vf[i+1]
+			} else {
+				x = vf[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[i] = x
+			switch {
+			case x > n:
+				kfEnd += 2 // off the right of the grid
+			case y > m:
+				kfStart += 2 // off the bottom of the grid
+			case front:
+				if j := offset + delta - k; j >= 0 && j < len(vb) && vb[j] != -1 && x >= n-vb[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + kbStart; k <= d-kbEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || k != d && vb[i-1] < vb[i+1] {
+				x = vb[i+1]
+			} else {
+				x = vb[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			vb[i] = x
+			switch {
+			case x > n:
+				kbEnd += 2
+			case y > m:
+				kbStart += 2
+			case !front:
+				if j := offset + delta - k; j >= 0 && j < len(vf) && vf[j] != -1 && vf[j] >= n-x {
+					x := vf[j]
+					return x, x - (delta - k), true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// writeHunks writes the edit script as unified diff
+// hunks with context lines of context.
+func writeHunks(w *bufio.Writer, ops []diffOp, context int) {
+	if context < 0 {
+		context = 0
+	}
+
+	// lineA and lineB are the line numbers, from 0, of
+	// ops[i] in each file
+	lineA := make([]int, len(ops)+1)
+	lineB := make([]int, len(ops)+1)
+	for i, op := range ops {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if op.kind != '+' {
+			lineA[i+1]++
+		}
+		if op.kind != '-' {
+			lineB[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// extend the hunk while changes are separated by
+		// at most 2*context unchanged lines
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		fmt.Fprintf(w, "@@ -%s +%s @@\n",
+			hunkRange(lineA[start], lineA[stop]-lineA[start]),
+			hunkRange(lineB[start], lineB[stop]-lineB[start]))
+		for _, op := range ops[start:stop] {
+			w.WriteByte(op.kind)
+			w.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				w.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+}
+
+// hunkRange formats the range of count lines starting
+// at line (from 0) as in GNU diff.
+func hunkRange(line, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line)
+	case 1:
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
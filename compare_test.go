@@ -0,0 +1,189 @@
+package gofile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareDirs(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	makeTree(t, a, "same", "touched", "edited", "onlya", "sub/x", "sub/deep/y", "gone/", "skip.log")
+	makeTree(t, b, "same", "touched", "edited", "onlyb", "sub/x", "sub/deep/y", "skip.log")
+
+	// equal times for the same files in both trees
+	stamp := time.Now().Add(-time.Hour)
+	for _, name := range []string{"same", "touched", "edited", "sub/x", "sub/deep/y"} {
+		os.Chtimes(filepath.Join(a, name), stamp, stamp)
+		os.Chtimes(filepath.Join(b, name), stamp, stamp)
+	}
+	os.Chtimes(filepath.Join(b, "touched"), stamp, time.Now())
+	// same size, different contents and time
+	os.WriteFile(filepath.Join(b, "edited"), []byte("EDITED"), NormalMode)
+	os.Chmod(filepath.Join(b, "sub/x"), 0600)
+
+	tests := []struct {
+		name string
+		opts CompareOptions
+		want []string
+	}{
+		{"metadata", CompareOptions{Exclude: []string{"*.log"}}, []string{
+			"Different edited mtime", "OnlyInA gone ", "OnlyInA onlya ", "OnlyInB onlyb ",
+			"Identical same ", "Identical sub/deep/y ", "Identical sub/x ", "Different touched mtime",
+		}},
+		{"content", CompareOptions{Content: true, Perm: true, Exclude: []string{"**/*.log"}}, []string{
+			"Different edited content", "OnlyInA gone ", "OnlyInA onlya ", "OnlyInB onlyb ",
+			"Identical same ", "Identical sub/deep/y ", "Different sub/x mode", "Identical touched ",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := CompareDirs(a, b, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Status.String()+" "+r.Path+" "+r.Reason)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareDirs() = %q, want %q", got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			if err := WriteDirDiff(&buf, a, b, results, 3); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if !strings.Contains(out, "Only in "+a+": onlya\n") || !strings.Contains(out, "-edited\n\\ No newline at end of file\n+EDITED\n") {
+				t.Errorf("WriteDirDiff() = %q", out)
+			}
+			if strings.Contains(out, "touched") {
+				t.Errorf("WriteDirDiff() reported identical contents: %q", out)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(from, to int, change map[int]string) string {
+		var b strings.Builder
+		for i := from; i <= to; i++ {
+			if s, ok := change[i]; ok {
+				b.WriteString(s)
+				continue
+			}
+			b.WriteString(strings.Repeat("x", i%5) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"identical", "a\nb\n", "a\nb\n", ""},
+		{"change", lines(1, 20, nil), lines(1, 20, map[int]string{10: "ten\n"}),
+			"@@ -7,7 +7,7 @@\n xx\n xxx\n xxxx\n-\n+ten\n x\n xx\n xxx\n"},
+		{"merged hunks", lines(1, 20, nil), lines(1, 20, map[int]string{5: "", 11: "eleven\n", 12: "twelve\n"}),
+			"@@ -2,14 +2,13 @@\n xx\n xxx\n xxxx\n-\n x\n xx\n xxx\n xxxx\n \n-x\n-xx\n+eleven\n+twelve\n xxx\n xxxx\n \n"},
+		{"separate hunks", lines(1, 20, nil), lines(1, 20, map[int]string{1: "one\n", 20: ""}),
+			"@@ -1,4 +1,4 @@\n-x\n+one\n xx\n xxx\n xxxx\n@@ -17,4 +17,3 @@\n xx\n xxx\n xxxx\n-\n"},
+		{"no newline", "a\nb", "a\nc\n", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n"},
+		{"empty", "", "a\n", "@@ -0,0 +1 @@\n+a\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pa, pb := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+			os.WriteFile(pa, []byte(tt.a), NormalMode)
+			os.WriteFile(pb, []byte(tt.b), NormalMode)
+
+			var buf bytes.Buffer
+			if err := UnifiedDiff(&buf, pa, pb, 3); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			if tt.want != "" {
+				header := strings.Index(got, "@@")
+				if !strings.HasPrefix(got, "--- "+pa+"\t") || header < 0 {
+					t.Fatalf("UnifiedDiff() = %q, want file headers", got)
+				}
+				got = got[header:]
+			}
+			if got != tt.want {
+				t.Errorf("UnifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines_space(t *testing.T) {
+	// every other line differs, so the script has as many
+	// edits as lines; a trace of the search frontiers
+	// would need gigabytes
+	const n = 5000
+	a, b := make([]string, n), make([]string, n)
+	for i := range a {
+		a[i] = strconv.Itoa(i) + "\n"
+		b[i] = a[i]
+		if i%2 == 1 {
+			b[i] = "changed\n"
+		}
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := diffLines(a, b)
+	runtime.ReadMemStats(&after)
+
+	var edits int
+	for _, op := range ops {
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if edits != n {
+		t.Errorf("diffLines() = %d edits, want %d", edits, n)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 64<<20 {
+		t.Errorf("diffLines() allocated %d bytes", alloc)
+	}
+}
+
+func TestCompareDirs_fs(t *testing.T) {
+	mem := NewMemFS()
+	for name, data := range map[string]string{"/a/same": "same\n", "/a/edited": "old\n", "/b/same": "same\n", "/b/edited": "new\n"} {
+		mem.MkdirAll(filepath.Dir(name), DirMode)
+		if err := WriteFileFS(mem, name, []byte(data), NormalMode, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := CompareDirs("/a", "/b", CompareOptions{Content: true, FS: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Status.String()+" "+r.Path+" "+r.Reason)
+	}
+	if want := []string{"Different edited content", "Identical same "}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("CompareDirs() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDirDiffFS(mem, &buf, "/a", "/b", results, 3); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "-old\n+new\n") {
+		t.Errorf("WriteDirDiffFS() = %q", out)
+	}
+}
@@ -2,16 +2,28 @@ package gofile
 
 import (
 	"io"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"path/filepath"
+	"sync/atomic"
 )
 
+// Copy copies the regular file src to dest. A new
+// dest is given the permissions of src less the umask,
+// as are the files created by the other copy functions.
 func Copy(src, dest string) (int64, error) {
-	return copy(src, dest)
+	return CopyFS(OSFS{}, src, dest)
 }
 
-func copy(src, dst string) (written int64, err error) {
-	sourceFileStat, err := os.Stat(src)
+// CopyFS copies the regular file src to dest within
+// fsys, as with Copy.
+func CopyFS(fsys FileSystem, src, dest string) (int64, error) {
+	return copy(fsys, src, dest, Umask())
+}
+
+// copy copies src to dst within fsys, creating dst with
+// the mode of src less umask.
+func copy(fsys FileSystem, src, dst string, umask fs.FileMode) (written int64, err error) {
+	sourceFileStat, err := fsys.Stat(src)
 	if err != nil {
 		return 0, Err(err)
 	}
@@ -20,40 +32,64 @@ func copy(src, dst string) (written int64, err error) {
 		return 0, NewGoFileError("source file not a regular file", src, err)
 	}
 
-	source, err := os.Open(src)
+	source, err := fsys.Open(src)
 	if err != nil {
 		return 0, NewGoFileError("unable to open source file", src, err)
 	}
 	defer source.Close()
 
-	destination, err := os.Create(dst)
+	destination, err := createFile(fsys, dst, sourceFileStat.Mode().Perm(), umask, false)
 	if err != nil {
-		return 0, NewGoFileError("unable to create destination file", dst, err)
+		return 0, err
 	}
 	defer destination.Close()
 
 	nBytes, err := io.Copy(destination, source)
-	return nBytes, NewGoFileError("invalid gofile copy result", src+" to "+dst, err)
+	if err != nil {
+		return nBytes, NewGoFileError("invalid gofile copy result", src+" to "+dst, err)
+	}
+	if err := destination.Close(); err != nil {
+		return nBytes, NewGoFileError("unable to close destination file", dst, err)
+	}
+	return nBytes, nil
 }
 
 func CopyUtil(src, dst string) (written int64, err error) {
-	buf, err := ioutil.ReadFile(src)
+	return CopyUtilFS(OSFS{}, src, dst)
+}
+
+// CopyUtilFS copies src to dst within fsys by reading
+// all of src into memory, as with CopyUtil.
+func CopyUtilFS(fsys FileSystem, src, dst string) (written int64, err error) {
+	fi, err := fsys.Stat(src)
+	if err != nil {
+		return 0, NewGoFileError("unable to read source file", src, err)
+	}
+
+	buf, err := ReadFileFS(fsys, src)
 	if err != nil {
 		return 0, NewGoFileError("unable to read source file into buffer", src, err)
 	}
 
 	n := len(buf)
 
-	err = ioutil.WriteFile(dst, buf, NormalMode)
+	err = WriteFileFS(fsys, dst, buf, fi.Mode().Perm(), false)
 	if err != nil {
-		return 0, NewGoFileError("unable to write destination file from buffer", dst, err)
+		return 0, err
 	}
 	return int64(n), nil
 }
 
 func CopyBuffer(src, dst string, buffersize int) (written int64, err error) {
+	return CopyBufferFS(OSFS{}, src, dst, buffersize)
+}
+
+// CopyBufferFS copies src to dst within fsys using a
+// buffer of buffersize bytes, as with CopyBuffer. The
+// number of bytes written before any error is returned.
+func CopyBufferFS(fsys FileSystem, src, dst string, buffersize int) (written int64, err error) {
 
-	fi, err := os.Stat(src)
+	fi, err := fsys.Stat(src)
 	if err != nil {
 		return 0, NewGoFileError("unable to read source file", src, err)
 	}
@@ -62,15 +98,15 @@ func CopyBuffer(src, dst string, buffersize int) (written int64, err error) {
 		return 0, NewGoFileError("source file not a regular file", src, err)
 	}
 
-	source, err := os.Open(src)
+	source, err := fsys.Open(src)
 	if err != nil {
 		return 0, NewGoFileError("unable to open source file", src, err)
 	}
 	defer source.Close()
 
-	destination, err := os.Create(dst)
+	destination, err := CreateFileFS(fsys, dst, fi.Mode().Perm(), false)
 	if err != nil {
-		return 0, NewGoFileError("unable to create destination file", dst, err)
+		return 0, err
 	}
 	defer destination.Close()
 
@@ -83,17 +119,90 @@ func CopyBuffer(src, dst string, buffersize int) (written int64, err error) {
 	buf := make([]byte, buffersize)
 
 	for {
-		n, err := source.Read(buf)
-		if err != nil && err != io.EOF {
-			return 0, NewGoFileError("error reading source file", src, err)
+		n, rerr := source.Read(buf)
+		if n > 0 {
+			wn, err := destination.Write(buf[:n])
+			nn += int64(wn)
+			if err == nil && wn < n {
+				err = io.ErrShortWrite
+			}
+			if err != nil {
+				return nn, NewGoFileError("error writing destination file", dst, err)
+			}
 		}
-		if n == 0 {
+		if rerr == io.EOF {
 			break
 		}
-		nn += int64(n)
-		if _, err := destination.Write(buf[:n]); err != nil {
-			return nn, NewGoFileError("error writing destination file", dst, err)
+		if rerr != nil {
+			return nn, NewGoFileError("error reading source file", src, rerr)
 		}
 	}
+	if err := destination.Close(); err != nil {
+		return nn, NewGoFileError("unable to close destination file", dst, err)
+	}
 	return nn, nil
 }
+
+// CopyTree copies the directory tree rooted at src to
+// dst using the default Walker. Regular files are
+// copied, directories are created and symbolic links
+// are recreated; other file types are skipped.
+//
+// The total number of bytes copied is returned.
+func CopyTree(src, dst string) (written int64, err error) {
+	return (&Walker{}).CopyTree(src, dst)
+}
+
+// CopyTree copies the directory tree rooted at src to
+// dst within w.FS, copying files concurrently as the
+// tree is walked.
+// Regular files are copied, directories are created and
+// symbolic links are recreated; other file types are
+// skipped, as are files matched by w.Ignore.
+//
+// The total number of bytes copied is returned.
+func (w *Walker) CopyTree(src, dst string) (written int64, err error) {
+	src = filepath.Clean(src)
+	dst = filepath.Clean(dst)
+	fsys := w.fs()
+	umask := Umask() // read once for the whole tree
+
+	err = w.Walk(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return NewGoFileError("unable to read source tree", path, err)
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return NewGoFileError("unable to determine relative path", path, err)
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			fi, err := d.Info()
+			if err != nil {
+				return NewGoFileError("unable to read source directory", path, err)
+			}
+			if err := mkdirAll(fsys, target, fi.Mode().Perm()|0700, umask, false); err != nil {
+				return err
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := fsys.Readlink(path)
+			if err != nil {
+				return NewGoFileError("unable to read symbolic link", path, err)
+			}
+			if err := fsys.Symlink(link, target); err != nil {
+				return NewGoFileError("unable to create symbolic link", target, err)
+			}
+		case d.Type().IsRegular():
+			n, err := copy(fsys, path, target, umask)
+			atomic.AddInt64(&written, n)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return atomic.LoadInt64(&written), err
+}
@@ -3,10 +3,10 @@ package copybenchmarks
 import (
 	"bufio"
 	"math/rand"
-	"os"
 	"time"
 
 	"github.com/skeptycal/errorlogger"
+	"github.com/skeptycal/goutil/gofile"
 )
 
 const fakesize = 2 << 16
@@ -25,34 +25,23 @@ func makebuf(size int) []byte {
 	return b
 }
 
-func makeFake(src, dest string) (*bufio.ReadWriter, error) {
+// fakeFS holds the benchmark files in memory, so that
+// they are not written into the source tree.
+var fakeFS = gofile.NewMemFS()
 
-	s, err := os.Create(src)
-	if err != nil {
-		return nil, err
-	}
-
-	d, err := os.Create(dest)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = s.Write(makebuf(fakesize))
-	if err != nil {
-		return nil, err
-	}
+func makeFake(src, dest string) (*bufio.ReadWriter, error) {
 
-	err = s.Sync()
+	err := gofile.WriteFileFS(fakeFS, src, makebuf(fakesize), gofile.NormalMode, false)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.Close()
+	d, err := gofile.CreateFileFS(fakeFS, dest, gofile.NormalMode, false)
 	if err != nil {
 		return nil, err
 	}
 
-	s, err = os.Open(src)
+	s, err := fakeFS.Open(src)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,115 @@
+package gofile
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// ListSummary counts the entries read from a directory
+// by whether they were listed or the reason they were
+// left out of the listing.
+type ListSummary struct {
+	Listed   int // entries included in the listing
+	Hidden   int // names beginning with '.' or matching a hide pattern
+	Backups  int // backup files, with names ending in '~'
+	Ignored  int // names matching an ignore pattern
+	Excluded int // files not matching an include pattern
+	NotDirs  int // files left out of a directories only listing
+}
+
+// Total returns the number of entries read.
+func (s ListSummary) Total() int {
+	return s.Listed + s.Hidden + s.Backups + s.Ignored + s.Excluded + s.NotDirs
+}
+
+// omitReason is the reason an entry is left out of a
+// listing; listed entries have no reason.
+type omitReason int
+
+const (
+	listed omitReason = iota
+	omitHidden
+	omitBackup
+	omitIgnored
+	omitExcluded
+	omitNotDir
+)
+
+func (s *ListSummary) add(r omitReason) {
+	switch r {
+	case listed:
+		s.Listed++
+	case omitHidden:
+		s.Hidden++
+	case omitBackup:
+		s.Backups++
+	case omitIgnored:
+		s.Ignored++
+	case omitExcluded:
+		s.Excluded++
+	case omitNotDir:
+		s.NotDirs++
+	}
+}
+
+// omit returns the reason the entry should be left out
+// of a listing, if any. Only the name and type of the
+// entry are used, so no file information is read.
+func (o *dirOptions) omit(d fs.DirEntry) omitReason {
+	name := d.Name()
+
+	switch {
+	case matchAny(o.ignore, name):
+		return omitIgnored
+	case o.ignoreBackups && isBackup(name):
+		return omitBackup
+	case strings.HasPrefix(name, ".") && !o.all && !o.almostAll:
+		return omitHidden
+	case !o.all && !o.almostAll && matchAny(o.hide, name):
+		return omitHidden
+	case o.dirOnly && !d.IsDir():
+		return omitNotDir
+	case len(o.include) > 0 && !d.IsDir() && !matchAny(o.include, name):
+		return omitExcluded
+	}
+	return listed
+}
+
+// checkPatterns returns an error wrapping ErrBadPattern
+// if any of the filter patterns is malformed.
+func (o *dirOptions) checkPatterns() error {
+	for _, list := range [][]string{o.ignore, o.hide, o.include} {
+		for _, pattern := range list {
+			if _, err := Match(pattern, ""); err != nil {
+				return NewGoFileError("invalid listing pattern", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether name matches any of the
+// glob patterns, using the syntax of Match.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isBackup reports whether name is a backup file name,
+// such as 'file~' or the numbered 'file.~1~'.
+func isBackup(name string) bool {
+	return strings.HasSuffix(name, "~")
+}
+
+// namedFile is a BasicFile listed under another name,
+// used for the '.' and '..' entries.
+type namedFile struct {
+	BasicFile
+	name string
+}
+
+func (f namedFile) Name() string { return f.name }
@@ -0,0 +1,79 @@
+package gofile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDirList_List_filter(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, ".hidden", ".config/", "a.go", "a.go~", "b.txt.~1~", "c.txt", "notes.md", "sub/", "vendor/")
+
+	tests := []struct {
+		name    string
+		options []DirOption
+		want    []string
+		summary ListSummary
+	}{
+		{"none", nil,
+			[]string{"sub", "vendor", "a.go", "a.go~", "b.txt.~1~", "c.txt", "notes.md"},
+			ListSummary{Listed: 7, Hidden: 2}},
+		{"almost all", []DirOption{WithAlmostAll(true)},
+			[]string{".config", "sub", "vendor", ".hidden", "a.go", "a.go~", "b.txt.~1~", "c.txt", "notes.md"},
+			ListSummary{Listed: 9}},
+		{"all", []DirOption{WithAll(true)},
+			[]string{".", "..", ".config", "sub", "vendor", ".hidden", "a.go", "a.go~", "b.txt.~1~", "c.txt", "notes.md"},
+			ListSummary{Listed: 11}},
+		{"ignore backups", []DirOption{WithIgnoreBackups(true)},
+			[]string{"sub", "vendor", "a.go", "c.txt", "notes.md"},
+			ListSummary{Listed: 5, Hidden: 2, Backups: 2}},
+		{"dirs only", []DirOption{WithAlmostAll(true), WithDirOnly(true)},
+			[]string{".config", "sub", "vendor"},
+			ListSummary{Listed: 3, NotDirs: 6}},
+		{"ignore", []DirOption{WithAlmostAll(true), WithIgnore("vendor", "*.md")},
+			[]string{".config", "sub", ".hidden", "a.go", "a.go~", "b.txt.~1~", "c.txt"},
+			ListSummary{Listed: 7, Ignored: 2}},
+		{"hide", []DirOption{WithHide("*.txt")},
+			[]string{"sub", "vendor", "a.go", "a.go~", "b.txt.~1~", "notes.md"},
+			ListSummary{Listed: 6, Hidden: 3}},
+		{"hide with almost all", []DirOption{WithAlmostAll(true), WithHide("*.txt")},
+			[]string{".config", "sub", "vendor", ".hidden", "a.go", "a.go~", "b.txt.~1~", "c.txt", "notes.md"},
+			ListSummary{Listed: 9}},
+		{"include", []DirOption{WithInclude("*.go", "*.md")},
+			[]string{"sub", "vendor", "a.go", "notes.md"},
+			ListSummary{Listed: 4, Hidden: 2, Excluded: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDIR(dir, append([]DirOption{WithDirsFirst(true)}, tt.options...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			list, err := d.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(list))
+			for _, f := range list {
+				got = append(got, baseName(f))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+			if s := d.Summary(); s != tt.summary {
+				t.Errorf("Summary() = %+v, want %+v", s, tt.summary)
+			}
+		})
+	}
+}
+
+func TestDirList_List_badPattern(t *testing.T) {
+	d, err := NewDIR(t.TempDir(), WithIgnore("[a-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.List(); !errors.Is(err, ErrBadPattern) {
+		t.Errorf("List() error = %v, want %v", err, ErrBadPattern)
+	}
+}
@@ -0,0 +1,165 @@
+package gofile
+
+import (
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+// DirIterator reads the entries of a directory in
+// batches, so that very large directories may be
+// processed without holding every entry in memory.
+//
+// Entries are returned in directory order; they are
+// not sorted. The file information of an entry is
+// only read from the file system when Info is called.
+//
+// A typical loop is:
+//
+//	it := d.Iter(0)
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Path())
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle error
+//	}
+type DirIterator struct {
+	dir     string // directory being read
+	n       int    // batch size
+	fsys    FileSystem
+	f       File // open directory; nil before first read and after close
+	batch   []fs.DirEntry
+	i       int         // index of the next entry in batch
+	entry   fs.DirEntry // current entry
+	info    fs.FileInfo // JIT file information for entry
+	infoErr error
+	pending error    // error to report once batch is consumed
+	list    *dirList // filters entries; nil lists every entry
+	err     error
+	done    bool
+}
+
+// Iter returns an iterator over the entries of the
+// directory that reads n entries at a time. If n <= 0,
+// DefaultDirBatchSize is used. Entries are filtered by
+// name and type using the listing options.
+//
+// The iterator must be closed if it is abandoned
+// before Next returns false.
+func (l *dirList) Iter(n int) *DirIterator {
+	it := NewDirIterator(l.Path(), n)
+	it.fsys = l.fs()
+	it.list = l.sub(l.Path())
+	if err := l.opts.checkPatterns(); err != nil {
+		it.err = err
+		it.done = true
+	}
+	return it
+}
+
+// NewDirIterator returns an iterator over the entries
+// of the directory dir that reads n entries at a time.
+// If n <= 0, DefaultDirBatchSize is used.
+func NewDirIterator(dir string, n int) *DirIterator {
+	if n <= 0 {
+		n = DefaultDirBatchSize
+	}
+	return &DirIterator{dir: dir, n: n, fsys: OSFS{}}
+}
+
+// Next advances the iterator to the next entry. It
+// returns false when there are no more entries, when
+// an error occurs, or after Close has been called.
+func (it *DirIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if it.f == nil {
+		f, err := it.fsys.Open(it.dir)
+		if err != nil {
+			it.err = NewGoFileError("unable to open directory", it.dir, err)
+			it.done = true
+			return false
+		}
+		it.f = f
+	}
+
+	for {
+		for it.i >= len(it.batch) {
+			if it.pending != nil {
+				if it.pending != io.EOF {
+					it.err = NewGoFileError("unable to read directory", it.dir, it.pending)
+				}
+				it.Close()
+				return false
+			}
+			it.batch, it.pending = it.f.ReadDir(it.n)
+			it.i = 0
+		}
+
+		entry := it.batch[it.i]
+		it.batch[it.i] = nil
+		it.i++
+		if it.list != nil && it.list.omit(it.dir, entry) != listed {
+			continue
+		}
+
+		it.entry = entry
+		it.info, it.infoErr = nil, nil
+		return true
+	}
+}
+
+// Entry returns the current entry.
+func (it *DirIterator) Entry() fs.DirEntry {
+	return it.entry
+}
+
+// Path returns the path of the current entry.
+func (it *DirIterator) Path() string {
+	if it.entry == nil {
+		return ""
+	}
+	return filepath.Join(it.dir, it.entry.Name())
+}
+
+// Info returns the file information for the current
+// entry. The file system is only consulted on the first
+// call for each entry. As with fs.DirEntry, symbolic
+// links are not followed.
+func (it *DirIterator) Info() (fs.FileInfo, error) {
+	if it.entry == nil {
+		return nil, NewGoFileError("no current directory entry", it.dir, ErrInvalid)
+	}
+	if it.info == nil && it.infoErr == nil {
+		it.info, it.infoErr = it.entry.Info()
+		if it.infoErr != nil {
+			it.infoErr = NewGoFileError("unable to read file information", it.Path(), it.infoErr)
+		}
+	}
+	return it.info, it.infoErr
+}
+
+// Err returns the first error encountered while
+// reading the directory, if any.
+func (it *DirIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and releases the open
+// directory. It is safe to call Close more than once.
+func (it *DirIterator) Close() error {
+	it.done = true
+	it.batch = nil
+	if it.f == nil {
+		return nil
+	}
+	err := it.f.Close()
+	it.f = nil
+	if err != nil {
+		return NewGoFileError("unable to close directory", it.dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package gofile
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDirIterator(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		makeTree(t, dir, fmt.Sprintf("file%d", i))
+	}
+
+	tests := []struct {
+		name  string
+		batch int
+		stop  int // stop after this many entries; 0 reads all
+		want  int
+	}{
+		{"default batch", 0, 0, 10},
+		{"small batch", 3, 0, 10},
+		{"single", 1, 0, 10},
+		{"early termination", 3, 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := NewDirIterator(dir, tt.batch)
+			defer it.Close()
+
+			got := 0
+			for it.Next() {
+				got++
+				if fi, err := it.Info(); err != nil || fi.Name() != it.Entry().Name() {
+					t.Errorf("Info() = %v, %v", fi, err)
+				}
+				if got == tt.stop {
+					it.Close()
+				}
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("iterated %d entries, want %d", got, tt.want)
+			}
+			if it.Next() {
+				t.Error("Next() = true after end of iteration")
+			}
+		})
+	}
+}
+
+func TestDirIterator_notExist(t *testing.T) {
+	it := NewDirIterator(t.TempDir()+"/missing", 0)
+	if it.Next() {
+		t.Error("Next() = true for missing directory")
+	}
+	if it.Err() == nil {
+		t.Error("Err() = nil for missing directory")
+	}
+}
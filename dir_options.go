@@ -1,5 +1,12 @@
 package gofile
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
 // SortType is a list of constants representing sort
 // methods for directory listings.
 type SortType int
@@ -28,32 +35,282 @@ func (s SortType) String() string {
 
 // dirOpts contains the options for directory listings.
 type dirOptions struct {
-	dirsfirst     bool   `default:"true"`
-	all           bool   `default:"true"`
-	almostAll     bool   `default:"true"`
-	author        bool   `default:"false"`
-	escape        bool   `default:"false"`
-	blockSize     string `default:"K"`
-	ignoreBackups bool   `default:"false"`
-	dirOnly       bool   `default:"false"`
-	color         bool   `default:"true"`
-	one           bool   `default:"false"`
-	columns       int    `default:"0"`
-	classify      bool   `default:"true"`
-	owner         bool   `default:"true"`
-	group         bool   `default:"true"`
-	sort          int    `default:"Alpha"`
-	revSort       bool   `default:"false"`
-	size          string `default:"K"`
-	human         bool   `default:"true"`
-	si            bool   `default:"true"`
-	inode         bool   `default:"true"`
-	dereference   bool   `default:"true"`
-	numeric       bool   `default:"false"`
-	slash         byte   `default:"'/'"`
-	quote         bool   `default:"false"`
-	recursive     bool   `default:"false"`
-	timeStyle     string `default:"time.Stamp"`
-}
-
-var defaultOptions = dirOptions{}
+	dirsfirst     bool       `default:"true"`
+	all           bool       `default:"false"`
+	almostAll     bool       `default:"true"`
+	author        bool       `default:"false"`
+	escape        bool       `default:"false"`
+	blockSize     string     `default:"K"`
+	ignoreBackups bool       `default:"false"`
+	dirOnly       bool       `default:"false"`
+	ignore        []string   `default:""`
+	hide          []string   `default:""`
+	include       []string   `default:""`
+	gitignore     bool       `default:"false"`
+	color         bool       `default:"true"`
+	one           bool       `default:"false"`
+	columns       int        `default:"0"`
+	classify      bool       `default:"true"`
+	owner         bool       `default:"true"`
+	group         bool       `default:"true"`
+	sort          int        `default:"Alpha"`
+	revSort       bool       `default:"false"`
+	size          string     `default:"K"`
+	human         bool       `default:"true"`
+	si            bool       `default:"true"`
+	inode         bool       `default:"true"`
+	dereference   bool       `default:"true"`
+	numeric       bool       `default:"false"`
+	slash         byte       `default:"'/'"`
+	quote         bool       `default:"false"`
+	recursive     bool       `default:"false"`
+	maxDepth      int        `default:"0"`
+	prune         bool       `default:"false"`
+	timeStyle     string     `default:"time.Stamp"`
+	fsys          FileSystem `default:""`
+}
+
+// defaultOptions are the options of listings before any
+// DirOption is applied. Only colors and classification
+// are on; other options are off or use their zero value.
+var defaultOptions = dirOptions{
+	color:    true,
+	classify: true,
+}
+
+// DirOption sets an option for directory listings.
+type DirOption func(*dirOptions)
+
+// WithSort sets the sort method for listings.
+func WithSort(s SortType) DirOption {
+	return func(o *dirOptions) { o.sort = int(s) }
+}
+
+// WithReverse reverses the sort order.
+func WithReverse(reverse bool) DirOption {
+	return func(o *dirOptions) { o.revSort = reverse }
+}
+
+// WithDirsFirst lists directories before files.
+func WithDirsFirst(dirsFirst bool) DirOption {
+	return func(o *dirOptions) { o.dirsfirst = dirsFirst }
+}
+
+// WithColor enables colored names in listings
+// written to a terminal.
+func WithColor(color bool) DirOption {
+	return func(o *dirOptions) { o.color = color }
+}
+
+// WithRecursive lists subdirectories recursively.
+func WithRecursive(recursive bool) DirOption {
+	return func(o *dirOptions) { o.recursive = recursive }
+}
+
+// WithMaxDepth limits recursive listings to depth
+// levels below the listed directory. Zero means
+// no limit.
+func WithMaxDepth(depth int) DirOption {
+	return func(o *dirOptions) { o.maxDepth = depth }
+}
+
+// WithPrune omits directories from recursive
+// listings if they contain no files.
+func WithPrune(prune bool) DirOption {
+	return func(o *dirOptions) { o.prune = prune }
+}
+
+// WithAll lists all entries, including those whose
+// names begin with '.', and the '.' and '..' entries.
+func WithAll(all bool) DirOption {
+	return func(o *dirOptions) { o.all = all }
+}
+
+// WithAlmostAll lists entries whose names begin with
+// '.', except for the '.' and '..' entries.
+func WithAlmostAll(almostAll bool) DirOption {
+	return func(o *dirOptions) { o.almostAll = almostAll }
+}
+
+// WithIgnoreBackups omits backup files, whose names
+// end with '~'.
+func WithIgnoreBackups(ignore bool) DirOption {
+	return func(o *dirOptions) { o.ignoreBackups = ignore }
+}
+
+// WithDirOnly lists directories only.
+func WithDirOnly(dirOnly bool) DirOption {
+	return func(o *dirOptions) { o.dirOnly = dirOnly }
+}
+
+// WithIgnore omits entries whose names match any of
+// the glob patterns, as with 'ls --ignore'.
+func WithIgnore(patterns ...string) DirOption {
+	return func(o *dirOptions) { o.ignore = append(o.ignore, patterns...) }
+}
+
+// WithHide omits entries whose names match any of
+// the glob patterns unless all or almostAll is set,
+// as with 'ls --hide'.
+func WithHide(patterns ...string) DirOption {
+	return func(o *dirOptions) { o.hide = append(o.hide, patterns...) }
+}
+
+// WithInclude lists only files whose names match one
+// of the glob patterns. Directories are not filtered,
+// so that recursive listings may descend into them.
+func WithInclude(patterns ...string) DirOption {
+	return func(o *dirOptions) { o.include = append(o.include, patterns...) }
+}
+
+// WithGitIgnore omits entries ignored by the .gitignore
+// and .ignore files of the enclosing git repository.
+func WithGitIgnore(gitignore bool) DirOption {
+	return func(o *dirOptions) { o.gitignore = gitignore }
+}
+
+// WithFS lists directories in fsys instead of the
+// host file system.
+func WithFS(fsys FileSystem) DirOption {
+	return func(o *dirOptions) { o.fsys = fsys }
+}
+
+// WithOwner shows the owner of each file in long
+// listings.
+func WithOwner(owner bool) DirOption {
+	return func(o *dirOptions) { o.owner = owner }
+}
+
+// WithGroup shows the group of each file in long
+// listings.
+func WithGroup(group bool) DirOption {
+	return func(o *dirOptions) { o.group = group }
+}
+
+// WithAuthor shows the author of each file in long
+// listings, which is the owner on Unix systems.
+func WithAuthor(author bool) DirOption {
+	return func(o *dirOptions) { o.author = author }
+}
+
+// WithNumeric shows user and group IDs instead of
+// names in long listings.
+func WithNumeric(numeric bool) DirOption {
+	return func(o *dirOptions) { o.numeric = numeric }
+}
+
+// WithHuman formats sizes in powers of 1024 with a
+// unit suffix, e.g. 1.5K, 234M, 2.0G.
+func WithHuman(human bool) DirOption {
+	return func(o *dirOptions) { o.human = human }
+}
+
+// WithSI formats sizes in powers of 1000 with a
+// unit suffix, e.g. 1.5k, 234M, 2.0G. It takes
+// precedence over WithHuman.
+func WithSI(si bool) DirOption {
+	return func(o *dirOptions) { o.si = si }
+}
+
+// WithBlockSize formats sizes as a number of blocks
+// of the given size, e.g. "K", "1M", "512" or "MB",
+// when neither human nor SI sizes are used.
+func WithBlockSize(size string) DirOption {
+	return func(o *dirOptions) { o.blockSize = size }
+}
+
+// FormatSize returns n bytes formatted using the size
+// rules of the default listing options modified by
+// any options given.
+func FormatSize(n int64, options ...DirOption) string {
+	opts := defaultOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts.formatSize(n)
+}
+
+// formatSize returns n bytes formatted with the SI,
+// human or block size rules, in that order of
+// precedence. Sizes are rounded up, as with du.
+func (o *dirOptions) formatSize(n int64) string {
+	switch {
+	case o.si:
+		return humanSize(n, 1000, "kMGTPE")
+	case o.human:
+		return humanSize(n, 1024, "KMGTPE")
+	}
+	unit := parseBlockSize(o.blockSize)
+	return strconv.FormatInt((n+unit-1)/unit, 10)
+}
+
+// humanSize formats n with one decimal place if the
+// scaled value is less than 10 and none otherwise.
+func humanSize(n int64, base float64, suffixes string) string {
+	if float64(n) < base {
+		return strconv.FormatInt(n, 10)
+	}
+	v := float64(n)
+	i := -1
+	for v >= base && i < len(suffixes)-1 {
+		v /= base
+		i++
+	}
+	if r := math.Ceil(v*10) / 10; r < 10 {
+		return fmt.Sprintf("%.1f%c", r, suffixes[i])
+	}
+	r := math.Ceil(v)
+	if r >= base && i < len(suffixes)-1 {
+		// rounding reached the next unit
+		return fmt.Sprintf("%.1f%c", math.Ceil(r/base*10)/10, suffixes[i+1])
+	}
+	return fmt.Sprintf("%.0f%c", r, suffixes[i])
+}
+
+// parseBlockSize returns the number of bytes in a block
+// size such as "K", "4K", "KiB", "KB" (1000) or "512".
+// Invalid sizes return 1024.
+func parseBlockSize(s string) int64 {
+	const fallback = 1024
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return fallback
+	}
+
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	n := int64(1)
+	if i > 0 {
+		v, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil || v <= 0 {
+			return fallback
+		}
+		n = v
+	}
+
+	suffix := strings.ToUpper(s[i:])
+	if suffix == "" {
+		return n
+	}
+
+	base := int64(1024)
+	switch {
+	case strings.HasSuffix(suffix, "IB"):
+		suffix = strings.TrimSuffix(suffix, "IB")
+	case strings.HasSuffix(suffix, "B") && len(suffix) == 2:
+		base = 1000
+		suffix = strings.TrimSuffix(suffix, "B")
+	}
+
+	p := strings.Index("KMGTPE", suffix)
+	if len(suffix) != 1 || p < 0 {
+		return fallback
+	}
+	for ; p >= 0; p-- {
+		n *= base
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package gofile
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sortFiles sorts list in place using the sort method,
+// direction and directories-first settings in opts.
+func sortFiles(list []BasicFile, opts dirOptions) {
+	less := fileLess(SortType(opts.sort))
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if opts.dirsfirst && a.IsDir() != b.IsDir() {
+			return a.IsDir()
+		}
+		if opts.revSort {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// fileLess returns the comparison function for a sort
+// method. Ties are broken by name so results are stable
+// between runs. Unknown methods sort alphabetically.
+func fileLess(s SortType) func(a, b fs.FileInfo) bool {
+	byName := func(a, b fs.FileInfo) bool {
+		return baseName(a) < baseName(b)
+	}
+
+	switch s {
+	case Size:
+		return func(a, b fs.FileInfo) bool {
+			if a.Size() != b.Size() {
+				return a.Size() > b.Size()
+			}
+			return byName(a, b)
+		}
+	case Version:
+		return func(a, b fs.FileInfo) bool {
+			if c := versionCompare(baseName(a), baseName(b)); c != 0 {
+				return c < 0
+			}
+			return byName(a, b)
+		}
+	case Extension:
+		return func(a, b fs.FileInfo) bool {
+			ea, eb := filepath.Ext(baseName(a)), filepath.Ext(baseName(b))
+			if ea != eb {
+				return ea < eb
+			}
+			return byName(a, b)
+		}
+	case Atime:
+		return func(a, b fs.FileInfo) bool {
+			ta, tb := accessTime(a), accessTime(b)
+			if !ta.Equal(tb) {
+				return ta.After(tb)
+			}
+			return byName(a, b)
+		}
+	case Ctime:
+		return func(a, b fs.FileInfo) bool {
+			ta, tb := changeTime(a), changeTime(b)
+			if !ta.Equal(tb) {
+				return ta.After(tb)
+			}
+			return byName(a, b)
+		}
+	}
+	return byName
+}
+
+// baseName returns the final path element of the
+// name reported by fi.
+func baseName(fi fs.FileInfo) string {
+	return filepath.Base(fi.Name())
+}
+
+// versionCompare compares two names treating runs of
+// digits as numbers, so that "file2" sorts before
+// "file10". It returns -1, 0 or +1.
+func versionCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+		switch {
+		case da && db:
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+			// compare numerically ignoring leading zeros
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				if len(ta) < len(tb) {
+					return -1
+				}
+				return 1
+			}
+			if ta != tb {
+				if ta < tb {
+					return -1
+				}
+				return 1
+			}
+			a, b = ra, rb
+		case a[0] != b[0]:
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		default:
+			a, b = a[1:], b[1:]
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// splitDigits splits s after its leading run of digits.
+func splitDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -1,6 +1,7 @@
 package gofile
 
 import (
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -19,17 +20,116 @@ type (
 
 		// Chdir changes the current working directory to the file, which must be a directory. If there is an error, it will be of type *PathError.
 		Chdir() error
+
+		// WriteTo writes the directory listing to w.
+		// If the recursive option is set, each
+		// subdirectory is listed in its own section.
+		io.WriterTo
+
+		// Summary returns the number of entries listed
+		// and left out of the listing, by reason.
+		Summary() ListSummary
+
+		// Iter returns an iterator that reads the
+		// directory n entries at a time.
+		Iter(n int) *DirIterator
+
+		// WriteTree writes the directory and its
+		// subdirectories to w as a tree.
+		WriteTree(w io.Writer) (TreeStats, error)
 	}
 )
 
 // type datafile =
 
 type dirList struct {
-	providedName string      // original name provided
-	name         string      // JIT absolute file name
-	count        int         // JIT cached file count
-	opts         dirOptions  // options for directory listing
-	list         []BasicFile // or []DataFile // fs.FileInfo
+	providedName string         // original name provided
+	name         string         // JIT absolute file name
+	count        int            // JIT cached file count
+	opts         dirOptions     // options for directory listing
+	list         []BasicFile    // or []DataFile // fs.FileInfo
+	colors       *LSColors      // JIT color map for listings
+	summary      ListSummary    // counts for the cached list
+	ignore       *IgnoreMatcher // JIT matcher for the gitignore option
+}
+
+// NewDIR returns a DIR for the named directory.
+// The default listing options are modified by
+// any options given.
+func NewDIR(name string, options ...DirOption) (DIR, error) {
+	opts := defaultOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	fsys := fsOrOS(opts.fsys)
+
+	// paths in other file systems are not relative to
+	// the working directory
+	name = filepath.Clean(name)
+	if isOS(fsys) {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, NewGoFileError("unable to determine absolute path", name, err)
+		}
+		name = abs
+	}
+
+	fi, err := fsys.Stat(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to stat directory", name, err)
+	}
+
+	if !fi.IsDir() {
+		return nil, NewGoFileError("not a directory", name, ErrInvalid)
+	}
+
+	return &dirList{
+		providedName: name,
+		name:         name,
+		opts:         opts,
+	}, nil
+}
+
+// sub returns a dirList for the subdirectory at path
+// that shares the options and colors of l.
+func (l *dirList) sub(path string) *dirList {
+	return &dirList{
+		providedName: path,
+		name:         path,
+		opts:         l.opts,
+		colors:       l.colors,
+		ignore:       l.ignore,
+	}
+}
+
+// fs returns the file system of the listing.
+func (l *dirList) fs() FileSystem {
+	return fsOrOS(l.opts.fsys)
+}
+
+// ignoreMatcher returns the matcher used for the
+// gitignore option, or nil if the option is not set.
+// Ignore files are only read from the host file system.
+func (l *dirList) ignoreMatcher() *IgnoreMatcher {
+	if !l.opts.gitignore || !isOS(l.fs()) {
+		return nil
+	}
+	if l.ignore == nil {
+		l.ignore = NewIgnoreMatcher(FindIgnoreRoot(l.Path()))
+	}
+	return l.ignore
+}
+
+// omit returns the reason the entry of the directory
+// should be left out of the listing, if any.
+func (l *dirList) omit(dir string, d fs.DirEntry) omitReason {
+	if reason := l.opts.omit(d); reason != listed {
+		return reason
+	}
+	if m := l.ignoreMatcher(); m != nil && m.Match(filepath.Join(dir, d.Name()), d.IsDir()) {
+		return omitIgnored
+	}
+	return listed
 }
 
 func (l *dirList) Len() int {
@@ -54,7 +154,7 @@ func (l *dirList) Dirs() []fs.FileInfo {
 
 func (l *dirList) Path() string {
 	if l.name == "" {
-		if !IsDir(l.providedName) {
+		if !IsDirFS(l.fs(), l.providedName) {
 			log.Errorf("%s is not a directory", l.providedName)
 			return ""
 		}
@@ -78,59 +178,85 @@ func (l *dirList) Abs() string {
 func (l *dirList) Dir() string  { return filepath.Dir(l.Abs()) }
 func (l *dirList) Base() string { return filepath.Base(l.Abs()) }
 
-// Returns the list of files in the directory.
+// Returns the list of files in the directory,
+// filtered and sorted as set in the listing options.
+// Entries are filtered by name and type before any
+// file information is read.
 //
 // If an error is encountered, that file will be
 // skipped and processing will continue.
 func (l *dirList) List() (fi []BasicFile, err error) {
-	if l.Len() == 0 {
+	if l.list == nil {
+		if err := l.opts.checkPatterns(); err != nil {
+			return nil, err
+		}
 
-		// path := l.Dir()
+		path := l.Path()
 
-		list, err := os.ReadDir(l.Path())
-		// list, err := ioutil.ReadDir(l.Path())
+		list, err := l.fs().ReadDir(path)
 		if err != nil {
-			return nil, err
+			return nil, NewGoFileError("unable to read directory", path, err)
 		}
+
+		l.summary = ListSummary{}
+		l.list = make([]BasicFile, 0, len(list)+2)
+
+		if l.opts.all {
+			for _, name := range []string{".", ".."} {
+				bf, err := newBasicFile(l.fs(), filepath.Join(path, name))
+				if err != nil {
+					Err(err)
+					continue
+				}
+				l.list = append(l.list, namedFile{bf, name})
+				l.summary.add(listed)
+			}
+		}
+
 		for _, dir := range list {
-			dir.Name()
-			bf, err := basicfile.NewBasicFile(dir.Name())
+			reason := l.omit(path, dir)
+			l.summary.add(reason)
+			if reason != listed {
+				continue
+			}
+			bf, err := newBasicFile(l.fs(), filepath.Join(path, dir.Name()))
 			if err != nil {
+				Err(err)
 				continue
 			}
-			list = append(list, bf)
+			l.list = append(l.list, bf)
 		}
+		sortFiles(l.list, l.opts)
+		l.count = len(l.list)
 	}
 	return l.list, nil
 }
 
+// Summary returns the number of entries listed and
+// left out of the most recent listing, by reason.
+func (l *dirList) Summary() ListSummary {
+	return l.summary
+}
+
+// SetOpts sets the listing options and clears
+// any cached listing.
 func (l *dirList) SetOpts(opts dirOptions) {
 	l.opts = opts
+	l.list = nil
+	l.count = 0
+	l.ignore = nil
+}
+
+// SetColors sets the color map used for listings.
+// If c is nil, the map is loaded from LS_COLORS
+// the next time a listing is written.
+func (l *dirList) SetColors(c *LSColors) {
+	l.colors = c
 }
 
-//  DIR interface {
-// 		Len() int
-// 		Path() string
-// 		List() ([]DataFile, error)
-// 		SetOpts(opts dirOpts)
-// 	}
-
-// func NewDIR(name string) (DIR, error) {
-// 	name, err := filepath.Abs(name)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	fi, err := os.Stat(name)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	if !fi.IsDir() {
-// 		return nil, fmt.Errorf("%s is not a directory", name)
-// 	}
-
-// 	return &dirList{
-// 		providedName: name,
-// 	}, nil
-// }
+func (l *dirList) Chdir() error {
+	if !isOS(l.fs()) {
+		return NewGoFileError("cannot change to a directory outside the host file system", l.Path(), ErrInvalid)
+	}
+	return os.Chdir(l.Path())
+}
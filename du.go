@@ -0,0 +1,226 @@
+package gofile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DirUsage is the disk usage of a directory, including
+// all of its subdirectories.
+type DirUsage struct {
+	Path     string
+	Apparent int64 // sum of apparent file sizes in bytes
+	Blocks   int64 // 512 byte blocks allocated
+	Files    int   // non-directory entries counted
+	Dirs     int   // directories counted, including Path
+}
+
+// Allocated returns the number of bytes allocated
+// on disk.
+func (u DirUsage) Allocated() int64 {
+	return u.Blocks * 512
+}
+
+// DiskUsageOptions are the options for DiskUsage.
+type DiskUsageOptions struct {
+	// OneFileSystem skips directories on file systems
+	// other than the one containing the root, as with
+	// 'du -x'.
+	OneFileSystem bool
+
+	// CountLinks counts files with multiple hard links
+	// each time they are found, as with 'du -l'. By
+	// default each inode is counted once.
+	CountLinks bool
+
+	// Workers is the number of directories read
+	// concurrently; see Walker.
+	Workers int
+
+	// Ignore, if not nil, skips matching files.
+	Ignore *IgnoreMatcher
+
+	// FS holds the tree measured, such as a MemFS in
+	// tests. The host file system is used if it is nil.
+	FS FileSystem
+}
+
+// DiskUsageErrors is the error returned by DiskUsage
+// when some files could not be read, with one error for
+// each of them.
+type DiskUsageErrors []error
+
+func (e DiskUsageErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e[0], len(e)-1)
+}
+
+// Is reports whether any of the errors matches target.
+func (e DiskUsageErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiskUsage walks the tree rooted at root and returns
+// the disk usage of each directory, sorted by path, so
+// that the total for root is first.
+//
+// As with du, files that cannot be read are reported
+// and the walk goes on; the totals of the files that
+// could be read are then returned with a
+// DiskUsageErrors.
+//
+// Allocated blocks are only available on Linux, macOS
+// and the BSDs; on other systems Blocks is zero. Symbolic links are
+// counted but not followed. Unless CountLinks is set, a
+// file with several hard links is counted once, in the
+// directory of its lexically first path.
+func DiskUsage(root string, opts DiskUsageOptions) ([]DirUsage, error) {
+	root = filepath.Clean(root)
+
+	var (
+		mu     sync.Mutex
+		dirs   = make(map[string]*DirUsage)
+		seen   = make(map[[2]uint64]string) // dev, ino of hard linked files -> path counted
+		rootFS uint64
+		errs   DiskUsageErrors
+	)
+
+	w := &Walker{Workers: opts.Workers, Ignore: opts.Ignore, FS: opts.FS}
+	report := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	err := w.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			report(NewGoFileError("unable to read directory for disk usage", path, err))
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			report(NewGoFileError("unable to stat file for disk usage", path, err))
+			if d.IsDir() {
+				return SkipDir
+			}
+			return nil
+		}
+		st, hasSys := statSys(fi)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if d.IsDir() {
+			if path == root {
+				rootFS = st.dev
+			} else if opts.OneFileSystem && hasSys && st.dev != rootFS {
+				return SkipDir
+			}
+			dirs[path] = &DirUsage{Path: path, Apparent: fi.Size(), Blocks: st.blocks, Dirs: 1}
+			return nil
+		}
+
+		if !opts.CountLinks && hasSys && st.nlink > 1 {
+			// the walk is concurrent, so credit the file to
+			// the lexically first of its links rather than to
+			// the first one visited
+			key := [2]uint64{st.dev, st.ino}
+			if first, ok := seen[key]; ok {
+				if path > first {
+					return nil
+				}
+				if u, ok := dirs[filepath.Dir(first)]; ok {
+					u.Apparent -= fi.Size()
+					u.Blocks -= st.blocks
+					u.Files--
+				}
+			}
+			seen[key] = path
+		}
+
+		parent, ok := dirs[filepath.Dir(path)]
+		if !ok {
+			// root is not a directory
+			parent = &DirUsage{Path: path}
+			dirs[path] = parent
+		}
+		parent.Apparent += fi.Size()
+		parent.Blocks += st.blocks
+		parent.Files++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]DirUsage, 0, len(dirs))
+	for _, u := range dirs {
+		usage = append(usage, *u)
+	}
+
+	// add each directory to its parent, deepest first
+	sort.Slice(usage, func(i, j int) bool {
+		di, dj := relDepth(root, usage[i].Path), relDepth(root, usage[j].Path)
+		if di != dj {
+			return di > dj
+		}
+		return usage[i].Path < usage[j].Path
+	})
+	index := make(map[string]int, len(usage))
+	for i, u := range usage {
+		index[u.Path] = i
+	}
+	for i := range usage {
+		u := usage[i]
+		if u.Path == root {
+			continue
+		}
+		p := &usage[index[filepath.Dir(u.Path)]]
+		p.Apparent += u.Apparent
+		p.Blocks += u.Blocks
+		p.Files += u.Files
+		p.Dirs += u.Dirs
+	}
+
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Path < usage[j].Path })
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return usage, errs
+	}
+	return usage, nil
+}
+
+// WriteDiskUsage writes one line for each directory in
+// usage to w, in the format of du: the size followed by
+// a tab and the path. Sizes are allocated bytes, or the
+// apparent size if apparent is true, formatted using the
+// size rules of the listing options.
+func WriteDiskUsage(w io.Writer, usage []DirUsage, apparent bool, options ...DirOption) error {
+	opts := defaultOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	for _, u := range usage {
+		n := u.Allocated()
+		if apparent {
+			n = u.Apparent
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", opts.formatSize(n), u.Path); err != nil {
+			return NewGoFileError("unable to write disk usage", u.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package gofile
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/1", "a/b/22", "c/333")
+	if err := os.Link(filepath.Join(root, "c/333"), filepath.Join(root, "a/b/link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dirSize := func(path string) int64 {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+
+	usage, err := DiskUsage(root, DiskUsageOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 4 || usage[0].Path != root {
+		t.Fatalf("DiskUsage() = %+v, want 4 directories starting with root", usage)
+	}
+
+	var dirs int64
+	for _, d := range []string{"", "a", "a/b", "c"} {
+		dirs += dirSize(filepath.Join(root, d))
+	}
+	// file contents are their names; the hard link is counted once
+	files := int64(len("a/1") + len("a/b/22") + len("c/333"))
+
+	total := usage[0]
+	if total.Apparent != dirs+files || total.Files != 3 || total.Dirs != 4 {
+		t.Errorf("DiskUsage() total = %+v, want Apparent %d, 3 files, 4 dirs", total, dirs+files)
+	}
+
+	usage, err = DiskUsage(root, DiskUsageOptions{CountLinks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := usage[0].Apparent, dirs+files+int64(len("c/333")); got != want {
+		t.Errorf("DiskUsage() with CountLinks apparent = %d, want %d", got, want)
+	}
+
+	// whatever the order of the walk, the hard link is
+	// credited to a/b/link rather than to c/333
+	for i := 0; i < 20; i++ {
+		usage, err = DiskUsage(root, DiskUsageOptions{Workers: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range usage {
+			if u.Path == filepath.Join(root, "c") && u.Files != 0 {
+				t.Fatalf("DiskUsage() credited the hard link to %s", u.Path)
+			}
+		}
+	}
+
+	usage, err = DiskUsage(root, DiskUsageOptions{CountLinks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteDiskUsage(buf, usage, true, WithSI(false), WithHuman(false), WithBlockSize("1")); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 4 {
+		t.Errorf("WriteDiskUsage() wrote %d lines, want 4", len(lines))
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int64
+		options []DirOption
+		want    string
+	}{
+		{"blocks default", 1025, nil, "2"},
+		{"si small", 999, []DirOption{WithSI(true)}, "999"},
+		{"si", 1500, []DirOption{WithSI(true)}, "1.5k"},
+		{"si large", 234_000_000, []DirOption{WithSI(true)}, "234M"},
+		{"si next unit", 999_999, []DirOption{WithSI(true)}, "1.0M"},
+		{"human", 1536, []DirOption{WithHuman(true)}, "1.5K"},
+		{"human round up", 1025, []DirOption{WithHuman(true)}, "1.1K"},
+		{"human round to 10", 10_230, []DirOption{WithHuman(true)}, "10K"},
+		{"human next unit", 1<<20 - 1, []DirOption{WithHuman(true)}, "1.0M"},
+		{"human gigabytes", 3 << 30, []DirOption{WithHuman(true)}, "3.0G"},
+		{"blocks K", 1025, []DirOption{WithBlockSize("K")}, "2"},
+		{"blocks 512", 1024, []DirOption{WithBlockSize("512")}, "2"},
+		{"blocks MB", 3_000_000, []DirOption{WithBlockSize("MB")}, "3"},
+		{"blocks MiB", 3 << 20, []DirOption{WithBlockSize("MiB")}, "3"},
+		{"blocks invalid", 2048, []DirOption{WithBlockSize("X")}, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSize(tt.n, tt.options...); got != tt.want {
+				t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskUsageErrors(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/1", "a/b/22", "c/333")
+	fsys := NewFaultFS(nil, Fault{Op: FaultReadDir, Pattern: "b", Err: syscall.EACCES})
+
+	usage, err := DiskUsage(root, DiskUsageOptions{FS: fsys})
+	var errs DiskUsageErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(err, syscall.EACCES) {
+		t.Fatalf("DiskUsage() error = %v, want one EACCES error", err)
+	}
+	if len(usage) != 4 || usage[0].Path != root {
+		t.Fatalf("DiskUsage() = %+v, want 4 directories starting with root", usage)
+	}
+	if total := usage[0]; total.Files != 2 || total.Dirs != 4 {
+		t.Errorf("DiskUsage() total = %+v, want 2 files and 4 dirs", total)
+	}
+}
@@ -0,0 +1,405 @@
+package gofile
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultOp is a set of FileSystem operations that a
+// Fault applies to.
+type FaultOp uint
+
+const (
+	FaultOpen    FaultOp = 1 << iota // Open and OpenFile
+	FaultRead                        // Read and ReadAt of a file
+	FaultWrite                       // Write, WriteAt and Truncate of a file
+	FaultStat                        // Stat, Lstat and Readlink
+	FaultReadDir                     // ReadDir of a file system or file
+	FaultSync                        // Sync of a file
+	FaultClose                       // Close of a file
+	FaultCreate                      // Mkdir, MkdirAll and Symlink
+	FaultRemove                      // Remove and RemoveAll
+	FaultRename                      // Rename
+	FaultChange                      // Chmod and Chtimes
+
+	FaultAll FaultOp = 1<<iota - 1
+)
+
+// Fault describes a failure injected by a FaultFS.
+//
+// For reads and writes, Err is returned once the file
+// offset reaches Offset; the bytes before Offset are
+// transferred first. Other operations return Err at once.
+type Fault struct {
+	Op      FaultOp       // operations affected, or all if zero
+	Pattern string        // names affected, as with Match, or all if empty
+	Err     error         // error returned, if any
+	Offset  int64         // offset at which reads and writes fail
+	Limit   int           // most bytes read or written per call, if not zero
+	Delay   time.Duration // delay before each operation
+}
+
+// NoSpaceAfter returns a Fault that fails writes to the
+// files matching pattern with ENOSPC after n bytes.
+func NoSpaceAfter(pattern string, n int64) Fault {
+	return Fault{Op: FaultWrite, Pattern: pattern, Err: syscall.ENOSPC, Offset: n}
+}
+
+// ReadErrorAt returns a Fault that fails reads of the
+// files matching pattern with EIO at offset off.
+func ReadErrorAt(pattern string, off int64) Fault {
+	return Fault{Op: FaultRead, Pattern: pattern, Err: syscall.EIO, Offset: off}
+}
+
+// DenyOpen returns a Fault that fails opening the files
+// matching pattern with EACCES.
+func DenyOpen(pattern string) Fault {
+	return Fault{Op: FaultOpen, Pattern: pattern, Err: syscall.EACCES}
+}
+
+// ShortWrites returns a Fault that writes at most n
+// bytes per call to the files matching pattern,
+// without an error.
+func ShortWrites(pattern string, n int) Fault {
+	return Fault{Op: FaultWrite, Pattern: pattern, Limit: n}
+}
+
+// SlowIO returns a Fault that delays reads and writes
+// of the files matching pattern by d.
+func SlowIO(pattern string, d time.Duration) Fault {
+	return Fault{Op: FaultRead | FaultWrite, Pattern: pattern, Delay: d}
+}
+
+// matches reports whether the fault applies to op on
+// name. A pattern without a slash is matched against
+// the base name, as in gitignore files.
+func (ft Fault) matches(op FaultOp, name string) bool {
+	if ft.Op != 0 && ft.Op&op == 0 {
+		return false
+	}
+	if ft.Pattern == "" {
+		return true
+	}
+	name = filepath.ToSlash(name)
+	if !strings.Contains(ft.Pattern, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := Match(ft.Pattern, name)
+	return ok
+}
+
+// clip applies the fault to a read or write of b at
+// off. It returns the part of b to transfer and the
+// error to return after it, if any.
+func (ft Fault) clip(b []byte, off int64) ([]byte, error) {
+	time.Sleep(ft.Delay)
+	if ft.Limit > 0 && len(b) > ft.Limit {
+		b = b[:ft.Limit]
+	}
+	if ft.Err == nil {
+		return b, nil
+	}
+	if off >= ft.Offset {
+		return b[:0], ft.Err
+	}
+	if off+int64(len(b)) > ft.Offset {
+		return b[:ft.Offset-off], ft.Err
+	}
+	return b, nil
+}
+
+// FaultFS is a FileSystem that injects failures into
+// the operations of another FileSystem, for testing
+// error handling. The first Fault that matches an
+// operation applies to it; operations without a
+// matching Fault are passed through.
+//
+// Faults may be added and cleared while the file
+// system is in use.
+type FaultFS struct {
+	FS FileSystem
+
+	mu     sync.Mutex
+	faults []Fault
+}
+
+// NewFaultFS returns a FaultFS that injects faults
+// into fsys, or the host file system if fsys is nil.
+func NewFaultFS(fsys FileSystem, faults ...Fault) *FaultFS {
+	return &FaultFS{FS: fsOrOS(fsys), faults: faults}
+}
+
+// Add adds faults after those already present.
+func (f *FaultFS) Add(faults ...Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = append(f.faults, faults...)
+}
+
+// Clear removes all faults.
+func (f *FaultFS) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = nil
+}
+
+// fault returns the fault for op on name, if any.
+func (f *FaultFS) fault(op FaultOp, name string) (Fault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ft := range f.faults {
+		if ft.matches(op, name) {
+			return ft, true
+		}
+	}
+	return Fault{}, false
+}
+
+// check applies the fault for an operation other than
+// a read or write, returning its error, if any.
+func (f *FaultFS) check(op FaultOp, opName, name string) error {
+	ft, ok := f.fault(op, name)
+	if !ok {
+		return nil
+	}
+	time.Sleep(ft.Delay)
+	if ft.Err != nil {
+		return &fs.PathError{Op: opName, Path: name, Err: ft.Err}
+	}
+	return nil
+}
+
+func (f *FaultFS) Open(name string) (File, error) {
+	return f.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (f *FaultFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	if err := f.check(FaultOpen, "open", name); err != nil {
+		return nil, err
+	}
+	file, err := f.FS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &faultFile{File: file, fsys: f, name: name, flag: flag}, nil
+}
+
+func (f *FaultFS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.check(FaultStat, "stat", name); err != nil {
+		return nil, err
+	}
+	return f.FS.Stat(name)
+}
+
+func (f *FaultFS) Lstat(name string) (fs.FileInfo, error) {
+	if err := f.check(FaultStat, "lstat", name); err != nil {
+		return nil, err
+	}
+	return f.FS.Lstat(name)
+}
+
+func (f *FaultFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.check(FaultReadDir, "readdirent", name); err != nil {
+		return nil, err
+	}
+	return f.FS.ReadDir(name)
+}
+
+func (f *FaultFS) Readlink(name string) (string, error) {
+	if err := f.check(FaultStat, "readlink", name); err != nil {
+		return "", err
+	}
+	return f.FS.Readlink(name)
+}
+
+func (f *FaultFS) Mkdir(name string, perm fs.FileMode) error {
+	if err := f.check(FaultCreate, "mkdir", name); err != nil {
+		return err
+	}
+	return f.FS.Mkdir(name, perm)
+}
+
+func (f *FaultFS) MkdirAll(path string, perm fs.FileMode) error {
+	if err := f.check(FaultCreate, "mkdir", path); err != nil {
+		return err
+	}
+	return f.FS.MkdirAll(path, perm)
+}
+
+func (f *FaultFS) Remove(name string) error {
+	if err := f.check(FaultRemove, "remove", name); err != nil {
+		return err
+	}
+	return f.FS.Remove(name)
+}
+
+func (f *FaultFS) RemoveAll(path string) error {
+	if err := f.check(FaultRemove, "unlinkat", path); err != nil {
+		return err
+	}
+	return f.FS.RemoveAll(path)
+}
+
+// Rename fails if a fault matches either name.
+func (f *FaultFS) Rename(oldpath, newpath string) error {
+	if err := f.check(FaultRename, "rename", oldpath); err != nil {
+		return err
+	}
+	if err := f.check(FaultRename, "rename", newpath); err != nil {
+		return err
+	}
+	return f.FS.Rename(oldpath, newpath)
+}
+
+func (f *FaultFS) Symlink(oldname, newname string) error {
+	if err := f.check(FaultCreate, "symlink", newname); err != nil {
+		return err
+	}
+	return f.FS.Symlink(oldname, newname)
+}
+
+func (f *FaultFS) Chmod(name string, mode fs.FileMode) error {
+	if err := f.check(FaultChange, "chmod", name); err != nil {
+		return err
+	}
+	return f.FS.Chmod(name, mode)
+}
+
+func (f *FaultFS) Chtimes(name string, atime, mtime time.Time) error {
+	if err := f.check(FaultChange, "chtimes", name); err != nil {
+		return err
+	}
+	return f.FS.Chtimes(name, atime, mtime)
+}
+
+// faultFile is a file opened in a FaultFS.
+type faultFile struct {
+	File
+	fsys *FaultFS
+	name string
+	flag int
+}
+
+func (f *faultFile) err(op string, err error) error {
+	return &fs.PathError{Op: op, Path: f.name, Err: err}
+}
+
+// offset returns the offset of the next Read or Write.
+func (f *faultFile) offset(write bool) (int64, error) {
+	if write && f.flag&os.O_APPEND != 0 {
+		fi, err := f.File.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return fi.Size(), nil
+	}
+	return f.File.Seek(0, io.SeekCurrent)
+}
+
+func (f *faultFile) Read(b []byte) (int, error) {
+	ft, ok := f.fsys.fault(FaultRead, f.name)
+	if !ok {
+		return f.File.Read(b)
+	}
+	off, err := f.offset(false)
+	if err != nil {
+		return 0, err
+	}
+	return f.transfer(ft, "read", b, off, f.File.Read)
+}
+
+func (f *faultFile) ReadAt(b []byte, off int64) (int, error) {
+	ft, ok := f.fsys.fault(FaultRead, f.name)
+	if !ok {
+		return f.File.ReadAt(b, off)
+	}
+	return f.transfer(ft, "read", b, off, func(b []byte) (int, error) { return f.File.ReadAt(b, off) })
+}
+
+func (f *faultFile) Write(b []byte) (int, error) {
+	ft, ok := f.fsys.fault(FaultWrite, f.name)
+	if !ok {
+		return f.File.Write(b)
+	}
+	off, err := f.offset(true)
+	if err != nil {
+		return 0, err
+	}
+	return f.transfer(ft, "write", b, off, f.File.Write)
+}
+
+func (f *faultFile) WriteAt(b []byte, off int64) (int, error) {
+	ft, ok := f.fsys.fault(FaultWrite, f.name)
+	if !ok {
+		return f.File.WriteAt(b, off)
+	}
+	return f.transfer(ft, "write", b, off, func(b []byte) (int, error) { return f.File.WriteAt(b, off) })
+}
+
+// transfer reads or writes the part of b at off allowed
+// by ft with fn, and returns the fault error if the
+// transfer reached it.
+func (f *faultFile) transfer(ft Fault, op string, b []byte, off int64, fn func([]byte) (int, error)) (int, error) {
+	b, ferr := ft.clip(b, off)
+	n := 0
+	if len(b) > 0 {
+		var err error
+		if n, err = fn(b); err != nil {
+			return n, err
+		}
+	}
+	if ferr != nil && n == len(b) {
+		return n, f.err(op, ferr)
+	}
+	return n, nil
+}
+
+// Truncate fails if a write fault with an error
+// applies at size.
+func (f *faultFile) Truncate(size int64) error {
+	if ft, ok := f.fsys.fault(FaultWrite, f.name); ok {
+		time.Sleep(ft.Delay)
+		if ft.Err != nil && size > ft.Offset {
+			return f.err("truncate", ft.Err)
+		}
+	}
+	return f.File.Truncate(size)
+}
+
+func (f *faultFile) Stat() (fs.FileInfo, error) {
+	if err := f.fsys.check(FaultStat, "stat", f.name); err != nil {
+		return nil, err
+	}
+	return f.File.Stat()
+}
+
+func (f *faultFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if err := f.fsys.check(FaultReadDir, "readdirent", f.name); err != nil {
+		return nil, err
+	}
+	return f.File.ReadDir(n)
+}
+
+func (f *faultFile) Sync() error {
+	if err := f.fsys.check(FaultSync, "sync", f.name); err != nil {
+		return err
+	}
+	return f.File.Sync()
+}
+
+// Close closes the underlying file even if a fault
+// applies, so that no file is left open.
+func (f *faultFile) Close() error {
+	err := f.File.Close()
+	if ferr := f.fsys.check(FaultClose, "close", f.name); ferr != nil {
+		return ferr
+	}
+	return err
+}
@@ -0,0 +1,167 @@
+package gofile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFaultFSPassThrough(t *testing.T) {
+	testFileSystem(t, NewFaultFS(NewMemFS()), "/")
+}
+
+func TestFaultFS(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10)
+
+	tests := []struct {
+		name    string
+		fault   Fault
+		fn      func(fsys FileSystem) (int64, error)
+		want    int64
+		wantErr error
+	}{
+		{"no space", NoSpaceAfter("dst", 64), copyFile, 64, syscall.ENOSPC},
+		{"no space elsewhere", NoSpaceAfter("other", 0), copyFile, 100, nil},
+		{"read error", ReadErrorAt("src", 30), copyFile, 30, syscall.EIO},
+		{"deny open", DenyOpen("src"), copyFile, 0, syscall.EACCES},
+		{"deny open by path", DenyOpen("/dir/dst"), copyFile, 0, syscall.EACCES},
+		{"short writes", ShortWrites("dst", 7), copyFile, 7, io.ErrShortWrite},
+		{"stat", Fault{Op: FaultStat, Err: syscall.EIO}, copyFile, 0, syscall.EIO},
+		{"close", Fault{Op: FaultClose, Pattern: "dst", Err: syscall.EIO}, closeFile, 0, syscall.EIO},
+		{"truncate", NoSpaceAfter("dst", 64), func(fsys FileSystem) (int64, error) {
+			f, err := fsys.OpenFile("/dir/dst", os.O_RDWR|os.O_CREATE, NormalMode)
+			if err != nil {
+				return 0, err
+			}
+			defer f.Close()
+			return 0, f.Truncate(65)
+		}, 0, syscall.ENOSPC},
+		{"append", NoSpaceAfter("src", 110), func(fsys FileSystem) (int64, error) {
+			f, err := fsys.OpenFile("/dir/src", os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				return 0, err
+			}
+			defer f.Close()
+			n, err := f.Write(data)
+			return int64(n), err
+		}, 10, syscall.ENOSPC},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := NewMemFS()
+			mem.MkdirAll("/dir", DirMode)
+			if err := WriteFileFS(mem, "/dir/src", data, NormalMode, false); err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.fn(NewFaultFS(mem, tt.fault))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("n = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func copyFile(fsys FileSystem) (int64, error) {
+	return CopyFS(fsys, "/dir/src", "/dir/dst")
+}
+
+func closeFile(fsys FileSystem) (int64, error) {
+	f, err := CreateFileFS(fsys, "/dir/dst", NormalMode, false)
+	if err != nil {
+		return 0, err
+	}
+	return 0, f.Close()
+}
+
+func TestFaultFSCopy(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	copies := []struct {
+		name string
+		fn   func(fsys FileSystem, src, dst string) (int64, error)
+	}{
+		{"CopyFS", CopyFS},
+		{"CopyUtilFS", CopyUtilFS},
+		{"CopyBufferFS", func(fsys FileSystem, src, dst string) (int64, error) {
+			return CopyBufferFS(fsys, src, dst, 16)
+		}},
+	}
+	faults := []struct {
+		name    string
+		fault   Fault
+		copied  int64 // bytes reported by CopyFS and CopyBufferFS
+		wantErr error
+	}{
+		{"open source", DenyOpen("src"), 0, syscall.EACCES},
+		{"open destination", DenyOpen("dst"), 0, syscall.EACCES},
+		{"read", ReadErrorAt("src", 30), 30, syscall.EIO},
+		{"write", NoSpaceAfter("dst", 64), 64, syscall.ENOSPC},
+		{"short write", ShortWrites("dst", 7), 7, io.ErrShortWrite},
+		{"close", Fault{Op: FaultClose, Pattern: "dst", Err: syscall.EIO}, 100, syscall.EIO},
+	}
+	for _, c := range copies {
+		for _, ft := range faults {
+			t.Run(c.name+"/"+ft.name, func(t *testing.T) {
+				mem := NewMemFS()
+				if err := WriteFileFS(mem, "/src", data, NormalMode, false); err != nil {
+					t.Fatal(err)
+				}
+				n, err := c.fn(NewFaultFS(mem, ft.fault), "/src", "/dst")
+				if !errors.Is(err, ft.wantErr) {
+					t.Errorf("error = %v, want %v", err, ft.wantErr)
+				}
+				want := ft.copied
+				if c.name == "CopyUtilFS" {
+					want = 0 // nothing is reported on error
+				}
+				if n != want {
+					t.Errorf("n = %d, want %d", n, want)
+				}
+			})
+		}
+	}
+}
+
+func TestFaultFSStatCheck(t *testing.T) {
+	mem := NewMemFS()
+	if err := WriteFileFS(mem, "/file", []byte("data"), 0600, false); err != nil {
+		t.Fatal(err)
+	}
+	fsys := NewFaultFS(mem)
+	if _, err := StatCheckFS(fsys, "/file"); err != nil {
+		t.Errorf("StatCheckFS() error = %v", err)
+	}
+	if _, err := StatCheckFS(fsys, "/file", WithAccess(AccessExecute)); !errors.Is(err, ErrPermission) {
+		t.Errorf("StatCheckFS(WithAccess(AccessExecute)) error = %v, want ErrPermission", err)
+	}
+	fsys.Add(Fault{Op: FaultStat, Err: syscall.EIO})
+	if _, err := StatCheckFS(fsys, "/file"); !errors.Is(err, syscall.EIO) {
+		t.Errorf("StatCheckFS() with a stat fault error = %v, want EIO", err)
+	}
+}
+
+func TestFaultFSSlowIO(t *testing.T) {
+	fsys := NewFaultFS(NewMemFS(), SlowIO("*", 20*time.Millisecond))
+	start := time.Now()
+	if err := WriteFileFS(fsys, "file", []byte("data"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("write took %v, want at least 20ms", d)
+	}
+
+	fsys.Clear()
+	start = time.Now()
+	if _, err := ReadFileFS(fsys, "file"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d >= 20*time.Millisecond {
+		t.Errorf("read after Clear took %v", d)
+	}
+}
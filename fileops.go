@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"syscall"
 
 	"github.com/pkg/errors"
 	"github.com/skeptycal/basicfile"
@@ -17,15 +18,81 @@ const (
 )
 
 func Exists(filename string) bool {
-	_, err := os.Stat(filename)
-	return errors.Is(err, os.ErrNotExist)
+	return ExistsFS(OSFS{}, filename)
 }
 
 func NotExists(filename string) bool {
-	_, err := os.Stat(filename)
+	return NotExistsFS(OSFS{}, filename)
+}
+
+// ExistsFS reports whether the named file exists
+// in fsys.
+func ExistsFS(fsys FileSystem, filename string) bool {
+	_, err := fsys.Stat(filename)
+	return err == nil
+}
+
+// NotExistsFS reports whether the named file is
+// known not to exist in fsys.
+func NotExistsFS(fsys FileSystem, filename string) bool {
+	_, err := fsys.Stat(filename)
 	return errors.Is(err, os.ErrNotExist)
 }
 
+// ExistsE reports whether the named file exists. It
+// returns false and a nil error only if the file is
+// known not to exist; if the answer cannot be known,
+// as when a directory may not be searched, it returns
+// the error as a GoFileError.
+func ExistsE(name string) (bool, error) {
+	fi, err := statE(os.Stat, name)
+	return fi != nil, err
+}
+
+// LExists is ExistsE for the named file itself; a
+// symbolic link exists even if its target does not.
+func LExists(name string) (bool, error) {
+	fi, err := statE(os.Lstat, name)
+	return fi != nil, err
+}
+
+// IsDirE reports whether the named file is a directory,
+// with errors as for ExistsE. A missing file is not a
+// directory.
+func IsDirE(name string) (bool, error) {
+	fi, err := statE(os.Stat, name)
+	return fi != nil && fi.IsDir(), err
+}
+
+// IsRegularE reports whether the named file is a
+// regular file, with errors as for ExistsE.
+func IsRegularE(name string) (bool, error) {
+	fi, err := statE(os.Stat, name)
+	return fi != nil && fi.Mode().IsRegular(), err
+}
+
+// IsSymlink reports whether the named file is a
+// symbolic link, with errors as for ExistsE.
+func IsSymlink(name string) (bool, error) {
+	fi, err := statE(os.Lstat, name)
+	return fi != nil && fi.Mode()&os.ModeSymlink != 0, err
+}
+
+// statE returns the file information of the named file
+// from stat, or nil if it is known not to exist. A path
+// through a file that is not a directory does not exist.
+func statE(stat func(string) (os.FileInfo, error), name string) (os.FileInfo, error) {
+	fi, err := stat(name)
+	switch {
+	case err == nil:
+		return fi, nil
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, syscall.ENOTDIR):
+		return nil, nil
+	default:
+		return nil, NewGoFileError("unable to determine whether file exists", name, err)
+	}
+}
+
 // Stat returns the os.FileInfo for file if it exists.
 //
 // It is a convenience wrapper for os.Stat that traps
@@ -35,7 +102,13 @@ func NotExists(filename string) bool {
 // If the file does not exist, nil is returned.
 // Errors are logged if Err is active.
 func Stat(filename string) os.FileInfo {
-	fi, err := os.Stat(filename)
+	return StatFS(OSFS{}, filename)
+}
+
+// StatFS returns the os.FileInfo for the named file
+// in fsys if it exists, as with Stat.
+func StatFS(fsys FileSystem, filename string) os.FileInfo {
+	fi, err := fsys.Stat(filename)
 	if err != nil {
 		Err(basicfile.NewGoFileError("gofile.Stat()", filename, err))
 		return nil
@@ -45,7 +118,13 @@ func Stat(filename string) os.FileInfo {
 
 // Mode returns the filemode of file.
 func Mode(filename string) os.FileMode {
-	fi, err := os.Stat(filename)
+	return ModeFS(OSFS{}, filename)
+}
+
+// ModeFS returns the filemode of the named file
+// in fsys.
+func ModeFS(fsys FileSystem, filename string) os.FileMode {
+	fi, err := fsys.Stat(filename)
 	if err != nil {
 		Err(NewGoFileError("gofile.Mode()", filename, err))
 		return 0
@@ -53,15 +132,26 @@ func Mode(filename string) os.FileMode {
 	return fi.Mode()
 }
 
-// StatCheck returns file information (after symlink evaluation)
-// using os.Stat(). If the file does not exist, is not a regular file,
-// or if the user lacks adequate permissions, an error is returned.
+// StatCheckOption sets an option of StatCheck.
+type StatCheckOption func(*statCheckOptions)
+
+type statCheckOptions struct {
+	access AccessMode // access required of the caller
+}
+
+// WithAccess sets the access the calling process must
+// have to the file for StatCheck to succeed. The
+// default is AccessRead.
+func WithAccess(mode AccessMode) StatCheckOption {
+	return func(o *statCheckOptions) { o.access = mode }
+}
+
 // StatCheck returns file information (after symlink evaluation
 // and path cleaning) using os.Stat().
 //
 // If the file does not exist, is not a regular file,
-// or if the user lacks adequate permissions, an error is
-// returned.
+// or if the calling process lacks the access set with
+// WithAccess, an error is returned.
 //
 // It is a convenience wrapper for os.Stat that traps
 // and processes errors that may occur using the
@@ -69,27 +159,42 @@ func Mode(filename string) os.FileMode {
 //
 // If the file does not exist, nil is returned.
 // Errors are logged if Err is active.
-func StatCheck(filename string) (os.FileInfo, error) {
+func StatCheck(filename string, options ...StatCheckOption) (os.FileInfo, error) {
+	return StatCheckFS(OSFS{}, filename, options...)
+}
 
-	// EvalSymlinks also calls Abs and Clean as well as
-	// checking for existance of the file.
-	filename, err := filepath.EvalSymlinks(filename)
-	if err != nil {
-		return nil, Err(NewGoFileError("gofile.StatCheck()#EvalSymlinks", filename, err))
+// StatCheckFS is StatCheck for the named file in fsys.
+// Outside the host file system, access is decided by
+// the permission bits of the file.
+func StatCheckFS(fsys FileSystem, filename string, options ...StatCheckOption) (os.FileInfo, error) {
+	opts := statCheckOptions{access: AccessRead}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if isOS(fsys) {
+		// EvalSymlinks also calls Abs and Clean as well as
+		// checking for existance of the file.
+		var err error
+		filename, err = filepath.EvalSymlinks(filename)
+		if err != nil {
+			return nil, Err(NewGoFileError("gofile.StatCheck()#EvalSymlinks", filename, err))
 
+		}
 	}
 
-	fi, err := os.Stat(filename)
+	fi, err := fsys.Stat(filename)
 	if err != nil {
 		return nil, Err(NewGoFileError("gofile.StatCheck()#os.Stat", filename, err))
 	}
 
-	//Check 'others' permission
-	m := fi.Mode()
-	if m&(1<<2) == 0 {
-		// err = Wrap(err, "")
-		return nil, Err(NewGoFileError("gofile.StatCheck()#insufficient_permissions", filename, ErrPermission))
-		fmt.Errorf("insufficient permissions: %v", filename)
+	if isOS(fsys) {
+		err = access(filename, opts.access)
+	} else {
+		err = accessInfo(fi, opts.access)
+	}
+	if err != nil {
+		return nil, Err(NewGoFileError("gofile.StatCheck()#insufficient_permissions", filename, err))
 	}
 
 	if fi.IsDir() {
@@ -0,0 +1,84 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExistsE(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "file", "dir/")
+	os.Symlink("file", filepath.Join(dir, "link"))
+	os.Symlink("missing", filepath.Join(dir, "dangling"))
+
+	type result struct{ exists, lexists, isDir, isRegular, isSymlink bool }
+	tests := []struct {
+		name string
+		want result
+	}{
+		{"file", result{true, true, false, true, false}},
+		{"dir", result{true, true, true, false, false}},
+		{"link", result{true, true, false, true, true}},
+		{"dangling", result{false, true, false, false, true}},
+		{"missing", result{}},
+		{"file/below", result{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(dir, tt.name)
+			var got result
+			var errs [5]error
+			got.exists, errs[0] = ExistsE(name)
+			got.lexists, errs[1] = LExists(name)
+			got.isDir, errs[2] = IsDirE(name)
+			got.isRegular, errs[3] = IsRegularE(name)
+			got.isSymlink, errs[4] = IsSymlink(name)
+			for _, err := range errs {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if os.Geteuid() == 0 {
+		return // root may search any directory
+	}
+	locked := filepath.Join(dir, "locked")
+	makeTree(t, dir, "locked/file")
+	os.Chmod(locked, 0)
+	defer os.Chmod(locked, DirMode)
+	if ok, err := ExistsE(filepath.Join(locked, "file")); ok || !errors.Is(err, ErrPermission) {
+		t.Errorf("ExistsE() in unsearchable directory = %v, %v, want false, ErrPermission", ok, err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "file")
+
+	tests := []struct {
+		name      string
+		exists    bool
+		notExists bool
+	}{
+		{"file", true, false},
+		{"missing", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(dir, tt.name)
+			if got := Exists(name); got != tt.exists {
+				t.Errorf("Exists() = %v, want %v", got, tt.exists)
+			}
+			if got := NotExists(name); got != tt.notExists {
+				t.Errorf("NotExists() = %v, want %v", got, tt.notExists)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package gofile
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/skeptycal/basicfile"
+)
+
+// File is an open file in a FileSystem. It is
+// implemented by *os.File.
+type File interface {
+	fs.File // Stat, Read and Close
+	io.Writer
+	io.Seeker
+	io.ReaderAt
+	io.WriterAt
+
+	// Name returns the name of the file as given to
+	// Open or OpenFile.
+	Name() string
+
+	// ReadDir reads the entries of a directory, as
+	// with fs.ReadDirFile.
+	ReadDir(n int) ([]fs.DirEntry, error)
+
+	Sync() error
+	Truncate(size int64) error
+}
+
+// FileSystem is a hierarchical file system that can be
+// read and written. Names are slash or OS separated
+// paths, as accepted by the os package; each method
+// behaves as the os function of the same name.
+//
+// OSFS is the file system of the host operating system.
+// Other implementations may keep files in memory or
+// restrict or modify access to another FileSystem.
+type FileSystem interface {
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Readlink(name string) (string, error)
+
+	Mkdir(name string, perm fs.FileMode) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
+	Symlink(oldname, newname string) error
+	Chmod(name string, mode fs.FileMode) error
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// OSFS is the FileSystem of the host operating system,
+// using the functions of the os package. The zero value
+// is ready to use.
+type OSFS struct{}
+
+func (OSFS) Open(name string) (File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err // a nil *os.File is not a nil File
+	}
+	return f, nil
+}
+
+func (OSFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error)        { return os.Stat(name) }
+func (OSFS) Lstat(name string) (fs.FileInfo, error)       { return os.Lstat(name) }
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error)   { return os.ReadDir(name) }
+func (OSFS) Readlink(name string) (string, error)         { return os.Readlink(name) }
+func (OSFS) Mkdir(name string, perm fs.FileMode) error    { return os.Mkdir(name, perm) }
+func (OSFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+func (OSFS) Remove(name string) error                     { return os.Remove(name) }
+func (OSFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (OSFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (OSFS) Symlink(oldname, newname string) error        { return os.Symlink(oldname, newname) }
+func (OSFS) Chmod(name string, mode fs.FileMode) error    { return os.Chmod(name, mode) }
+func (OSFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// fsOrOS returns fsys, or OSFS if fsys is nil.
+func fsOrOS(fsys FileSystem) FileSystem {
+	if fsys == nil {
+		return OSFS{}
+	}
+	return fsys
+}
+
+// isOS reports whether fsys is the host file system.
+func isOS(fsys FileSystem) bool {
+	_, ok := fsOrOS(fsys).(OSFS)
+	return ok
+}
+
+// ReadFileFS returns the contents of the named file
+// in fsys, as with os.ReadFile.
+func ReadFileFS(fsys FileSystem, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to open file", name, err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, NewGoFileError("unable to read file", name, err)
+	}
+	return b, nil
+}
+
+// infoFile is a BasicFile for a file in a FileSystem
+// other than OSFS. Only the fs.FileInfo methods may
+// be used.
+type infoFile struct {
+	BasicFile
+	fi fs.FileInfo
+}
+
+func (f infoFile) Name() string       { return f.fi.Name() }
+func (f infoFile) Size() int64        { return f.fi.Size() }
+func (f infoFile) Mode() fs.FileMode  { return f.fi.Mode() }
+func (f infoFile) ModTime() time.Time { return f.fi.ModTime() }
+func (f infoFile) IsDir() bool        { return f.fi.IsDir() }
+func (f infoFile) Sys() interface{}   { return f.fi.Sys() }
+
+func (f infoFile) Type() fs.FileMode          { return f.fi.Mode().Type() }
+func (f infoFile) Info() (fs.FileInfo, error) { return f.fi, nil }
+
+// newBasicFile returns a BasicFile for the named file
+// in fsys.
+func newBasicFile(fsys FileSystem, name string) (BasicFile, error) {
+	if isOS(fsys) {
+		return basicfile.NewBasicFile(name)
+	}
+	fi, err := fsys.Lstat(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to stat file", name, err)
+	}
+	return infoFile{fi: fi}, nil
+}
@@ -0,0 +1,151 @@
+package gofile
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testFileSystem checks the behavior shared by every
+// FileSystem, using the empty directory root in fsys.
+func testFileSystem(t *testing.T, fsys FileSystem, root string) {
+	t.Helper()
+	join := func(name string) string { return filepath.Join(root, name) }
+
+	if err := fsys.MkdirAll(join("a/b"), DirMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileFS(fsys, join("a/file"), []byte("hello"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ReadFileFS(fsys, join("a/file")); err != nil || string(b) != "hello" {
+		t.Fatalf("ReadFileFS() = %q, %v, want hello", b, err)
+	}
+
+	// OpenFile flags
+	f, err := fsys.OpenFile(join("a/file"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(", world"))
+	f.Close()
+	if _, err := fsys.OpenFile(join("a/file"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, NormalMode); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("OpenFile(O_EXCL) error = %v, want ErrExist", err)
+	}
+	f, err = fsys.Open(join("a/file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := f.ReadAt(buf, 7); err != nil || string(buf) != "world" {
+		t.Errorf("ReadAt() = %q, %v, want world", buf, err)
+	}
+	if pos, err := f.Seek(-5, io.SeekEnd); err != nil || pos != 7 {
+		t.Errorf("Seek() = %d, %v, want 7", pos, err)
+	}
+	f.Close()
+
+	// metadata
+	if err := fsys.Chmod(join("a/file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := fsys.Chtimes(join("a/file"), stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := fsys.Stat(join("a/file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Name() != "file" || fi.Size() != 12 || fi.Mode() != 0600 || !fi.ModTime().Equal(stamp) {
+		t.Errorf("Stat() = %v %d %v %v", fi.Name(), fi.Size(), fi.Mode(), fi.ModTime())
+	}
+
+	// links and renames
+	if err := fsys.Symlink("file", join("a/link")); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := fsys.Readlink(join("a/link")); err != nil || target != "file" {
+		t.Errorf("Readlink() = %q, %v, want file", target, err)
+	}
+	if fi, err := fsys.Lstat(join("a/link")); err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Lstat() = %v, %v, want a symbolic link", fi, err)
+	}
+	if fi, err := fsys.Stat(join("a/link")); err != nil || !fi.Mode().IsRegular() {
+		t.Errorf("Stat() of link = %v, %v, want a regular file", fi, err)
+	}
+	if err := fsys.Rename(join("a/b"), join("c")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir(join("c/d"), DirMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir(join("c/d"), DirMode); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Mkdir() error = %v, want ErrExist", err)
+	}
+
+	entries, err := fsys.ReadDir(join("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"file", "link"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir() = %v, want %v", names, want)
+	}
+
+	// package functions
+	if !ExistsFS(fsys, join("a/file")) || ExistsFS(fsys, join("missing")) || !NotExistsFS(fsys, join("missing")) {
+		t.Error("ExistsFS() and NotExistsFS() disagree with the file system")
+	}
+	if !IsDirFS(fsys, join("c/d")) || IsDirFS(fsys, join("a/file")) || !IsRegularFS(fsys, join("a/file")) {
+		t.Error("IsDirFS() and IsRegularFS() disagree with the file system")
+	}
+	if n, err := CopyFS(fsys, join("a/file"), join("c/copy")); err != nil || n != 12 {
+		t.Errorf("CopyFS() = %d, %v, want 12", n, err)
+	}
+	if n, err := (&Walker{FS: fsys}).CopyTree(join("a"), join("tree")); err != nil || n != 12 {
+		t.Errorf("CopyTree() = %d, %v, want 12", n, err)
+	}
+
+	d, err := NewDIR(root, WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := d.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = nil
+	for _, f := range list {
+		names = append(names, baseName(f))
+	}
+	if want := []string{"a", "c", "tree"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	// removal
+	if err := fsys.Remove(join("c")); err == nil {
+		t.Error("Remove() of a non-empty directory succeeded")
+	}
+	if err := fsys.RemoveAll(join("c")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove(join("a/link")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Lstat(join("c/d")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Lstat() after RemoveAll error = %v, want ErrNotExist", err)
+	}
+}
+
+func TestOSFS(t *testing.T) {
+	testFileSystem(t, OSFS{}, t.TempDir())
+}
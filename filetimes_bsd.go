@@ -0,0 +1,30 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package gofile
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// accessTime returns the last access time of the file
+// described by fi. If fi does not carry system stat
+// data, the modification time is returned.
+func accessTime(fi fs.FileInfo) time.Time {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atimespec.Unix())
+	}
+	return fi.ModTime()
+}
+
+// changeTime returns the last status change time of
+// the file described by fi. If fi does not carry system
+// stat data, the modification time is returned.
+func changeTime(fi fs.FileInfo) time.Time {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Ctimespec.Unix())
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package gofile
+
+import (
+	"io/fs"
+	"time"
+)
+
+// accessTime returns the modification time of the file
+// described by fi; access times are only available on
+// Linux, macOS and the BSDs.
+func accessTime(fi fs.FileInfo) time.Time { return fi.ModTime() }
+
+// changeTime returns the modification time of the file
+// described by fi; status change times are only
+// available on Linux, macOS and the BSDs.
+func changeTime(fi fs.FileInfo) time.Time { return fi.ModTime() }
@@ -0,0 +1,433 @@
+package gofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// FindEntry is a file considered by Find. File
+// information is read on first use, so predicates that
+// only test the name or type do not stat the file.
+type FindEntry struct {
+	Path  string // path including the root given to Find
+	Rel   string // path relative to the root
+	Depth int    // levels below the root; the root is 0
+
+	d       fs.DirEntry
+	fsys    FileSystem
+	info    fs.FileInfo
+	infoErr error
+}
+
+// Name returns the base name of the file.
+func (e *FindEntry) Name() string { return e.d.Name() }
+
+// Type returns the type bits of the file mode.
+func (e *FindEntry) Type() fs.FileMode { return e.d.Type() }
+
+// IsDir reports whether the file is a directory.
+func (e *FindEntry) IsDir() bool { return e.d.IsDir() }
+
+// Info returns the file information. Symbolic links
+// are not followed.
+func (e *FindEntry) Info() (fs.FileInfo, error) {
+	if e.info == nil && e.infoErr == nil {
+		e.info, e.infoErr = e.d.Info()
+	}
+	return e.info, e.infoErr
+}
+
+// A Predicate reports whether a file matches a query.
+type Predicate func(e *FindEntry) bool
+
+// withInfo returns a predicate that applies fn to the
+// file information; files that cannot be read do not
+// match.
+func withInfo(fn func(fi fs.FileInfo) bool) Predicate {
+	return func(e *FindEntry) bool {
+		fi, err := e.Info()
+		return err == nil && fn(fi)
+	}
+}
+
+// And matches files that match all of the predicates.
+func And(predicates ...Predicate) Predicate {
+	return func(e *FindEntry) bool {
+		for _, p := range predicates {
+			if !p(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches files that match any of the predicates.
+func Or(predicates ...Predicate) Predicate {
+	return func(e *FindEntry) bool {
+		for _, p := range predicates {
+			if p(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches files that do not match p.
+func Not(p Predicate) Predicate {
+	return func(e *FindEntry) bool { return !p(e) }
+}
+
+// True matches every file.
+func True() Predicate {
+	return func(e *FindEntry) bool { return true }
+}
+
+// NameMatch matches files whose base name matches the
+// glob pattern, as with 'find -name', using the syntax
+// of Match. A malformed pattern returns an error
+// wrapping ErrBadPattern.
+func NameMatch(pattern string) (Predicate, error) {
+	if _, err := Match(pattern, ""); err != nil {
+		return nil, NewGoFileError("invalid name pattern", pattern, err)
+	}
+	return func(e *FindEntry) bool {
+		ok, _ := Match(pattern, e.Name())
+		return ok
+	}, nil
+}
+
+// PathMatch matches files whose path relative to the
+// root given to Find matches the glob pattern, which
+// may contain '**'. The root itself has the path ".".
+func PathMatch(pattern string) (Predicate, error) {
+	if _, err := Match(pattern, ""); err != nil {
+		return nil, NewGoFileError("invalid path pattern", pattern, err)
+	}
+	return func(e *FindEntry) bool {
+		ok, _ := Match(pattern, filepath.ToSlash(e.Rel))
+		return ok
+	}, nil
+}
+
+// NameRegexp matches files whose base name matches the
+// regular expression.
+func NameRegexp(expr string) (Predicate, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, NewGoFileError("invalid name expression", expr, err)
+	}
+	return func(e *FindEntry) bool { return re.MatchString(e.Name()) }, nil
+}
+
+// PathRegexp matches files whose path, including the
+// root given to Find, matches the regular expression.
+func PathRegexp(expr string) (Predicate, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, NewGoFileError("invalid path expression", expr, err)
+	}
+	return func(e *FindEntry) bool { return re.MatchString(e.Path) }, nil
+}
+
+// FileType matches files of the given type, one of 0
+// for regular files, fs.ModeDir, fs.ModeSymlink,
+// fs.ModeNamedPipe, fs.ModeSocket, fs.ModeDevice or
+// fs.ModeDevice|fs.ModeCharDevice.
+func FileType(t fs.FileMode) Predicate {
+	t &= fs.ModeType
+	return func(e *FindEntry) bool { return e.Type() == t }
+}
+
+// SizeRange matches files with a size of at least min
+// and at most max bytes. A negative max has no upper
+// limit.
+func SizeRange(min, max int64) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool {
+		return fi.Size() >= min && (max < 0 || fi.Size() <= max)
+	})
+}
+
+// LargerThan matches files larger than n bytes.
+func LargerThan(n int64) Predicate { return SizeRange(n+1, -1) }
+
+// SmallerThan matches files smaller than n bytes; if n
+// is not positive, no file matches.
+func SmallerThan(n int64) Predicate {
+	if n <= 0 {
+		return Not(True())
+	}
+	return SizeRange(0, n-1)
+}
+
+// inRange reports whether t is within [from, to]. A zero
+// from or to is unbounded.
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+// ModTimeRange matches files last modified between from
+// and to, inclusive. A zero time is unbounded.
+func ModTimeRange(from, to time.Time) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool { return inRange(fi.ModTime(), from, to) })
+}
+
+// AccessTimeRange matches files last accessed between
+// from and to, inclusive. A zero time is unbounded.
+func AccessTimeRange(from, to time.Time) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool { return inRange(accessTime(fi), from, to) })
+}
+
+// ChangeTimeRange matches files whose status last
+// changed between from and to, inclusive. A zero time
+// is unbounded.
+func ChangeTimeRange(from, to time.Time) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool { return inRange(changeTime(fi), from, to) })
+}
+
+// NotModifiedFor matches files that have not been
+// modified for at least d, as with 'find -mtime +N'.
+func NotModifiedFor(d time.Duration) Predicate {
+	return ModTimeRange(time.Time{}, time.Now().Add(-d))
+}
+
+// NewerThanFile matches files modified more recently
+// than the named file, as with 'find -newer'.
+func NewerThanFile(name string) (Predicate, error) {
+	return NewerThanFileFS(nil, name)
+}
+
+// NewerThanFileFS is NewerThanFile with the reference
+// file read from fsys, which should be the FS searched.
+// If fsys is nil, the host file system is used.
+func NewerThanFileFS(fsys FileSystem, name string) (Predicate, error) {
+	ref, err := fsOrOS(fsys).Stat(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to stat reference file", name, err)
+	}
+	t := ref.ModTime()
+	return withInfo(func(fi fs.FileInfo) bool { return fi.ModTime().After(t) }), nil
+}
+
+// PermExact matches files whose permission bits,
+// including setuid, setgid and sticky, are exactly perm,
+// as with 'find -perm mode'.
+func PermExact(perm fs.FileMode) Predicate {
+	mask := fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+	return withInfo(func(fi fs.FileInfo) bool { return fi.Mode()&mask == perm&mask })
+}
+
+// PermAll matches files with all of the permission bits
+// set, as with 'find -perm -mode'.
+func PermAll(perm fs.FileMode) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool { return fi.Mode()&perm == perm })
+}
+
+// PermAny matches files with any of the permission bits
+// set, as with 'find -perm /mode'.
+func PermAny(perm fs.FileMode) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool { return perm == 0 || fi.Mode()&perm != 0 })
+}
+
+// Owner matches files owned by the user id. Ownership
+// is only available on Linux, macOS and the BSDs.
+func Owner(uid int) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool {
+		st, ok := statSys(fi)
+		return ok && int(st.uid) == uid
+	})
+}
+
+// Group matches files owned by the group id. Ownership
+// is only available on Linux, macOS and the BSDs.
+func Group(gid int) Predicate {
+	return withInfo(func(fi fs.FileInfo) bool {
+		st, ok := statSys(fi)
+		return ok && int(st.gid) == gid
+	})
+}
+
+// Empty matches empty regular files and directories
+// with no entries, as with 'find -empty'.
+func Empty() Predicate {
+	return func(e *FindEntry) bool {
+		switch {
+		case e.IsDir():
+			f, err := e.fsys.Open(e.Path)
+			if err != nil {
+				return false
+			}
+			defer f.Close()
+			_, err = f.ReadDir(1)
+			return err == io.EOF
+		case e.Type().IsRegular():
+			fi, err := e.Info()
+			return err == nil && fi.Size() == 0
+		}
+		return false
+	}
+}
+
+// FindOptions are the options for Find and FindStream.
+type FindOptions struct {
+	// MinDepth skips files less than MinDepth levels
+	// below the root; the root is at depth 0.
+	MinDepth int
+
+	// MaxDepth does not descend more than MaxDepth
+	// levels below the root. If MaxDepth <= 0, there
+	// is no limit.
+	MaxDepth int
+
+	// Workers is the number of directories read
+	// concurrently; see Walker.
+	Workers int
+
+	// Ordered returns results from FindStream in the
+	// order of filepath.WalkDir. Find always does.
+	Ordered bool
+
+	// Ignore, if not nil, skips matching files.
+	Ignore *IgnoreMatcher
+
+	// FS, if not nil, is searched in place of the
+	// host file system.
+	FS FileSystem
+
+	// StopOnError ends the search at the first directory
+	// that cannot be read. By default, as with find, the
+	// error is reported and the search goes on.
+	StopOnError bool
+}
+
+// FindErrors is the error returned by Find when some
+// directories could not be read, with one error for
+// each of them.
+type FindErrors []error
+
+func (e FindErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", e[0], len(e)-1)
+}
+
+// Is reports whether any of the errors matches target.
+func (e FindErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindResult is a file matched by FindStream or, if
+// Err is not nil, a directory that could not be read.
+// Unless StopOnError is set, the search goes on after
+// an error.
+type FindResult struct {
+	*FindEntry
+	Err error
+}
+
+// findWalk walks root calling fn for each file that
+// matches, until fn returns an error. Directories that
+// cannot be read are passed to errFn, unless
+// StopOnError is set.
+func findWalk(root string, match Predicate, opts FindOptions, fn func(e *FindEntry) error, errFn func(err error) error) error {
+	if match == nil {
+		match = True()
+	}
+	w := &Walker{Workers: opts.Workers, Ordered: opts.Ordered, MaxDepth: opts.MaxDepth, Ignore: opts.Ignore, FS: opts.FS}
+	return w.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			err = NewGoFileError("unable to read directory", path, err)
+			if opts.StopOnError {
+				return err
+			}
+			return errFn(err)
+		}
+		rel, _ := filepath.Rel(root, path)
+		e := &FindEntry{Path: path, Rel: rel, Depth: relDepth(root, path), d: d, fsys: w.fs()}
+		if e.Depth < opts.MinDepth || !match(e) {
+			return nil
+		}
+		return fn(e)
+	})
+}
+
+// Find walks the tree rooted at root and returns the
+// files that match, in the order of filepath.WalkDir.
+// If match is nil, every file matches.
+//
+// Directories that cannot be read are skipped and the
+// files found elsewhere are returned with a FindErrors,
+// unless StopOnError is set.
+func Find(root string, match Predicate, opts FindOptions) ([]BasicFile, error) {
+	opts.Ordered = true
+
+	var (
+		found []BasicFile
+		errs  FindErrors
+	)
+	err := findWalk(root, match, opts, func(e *FindEntry) error {
+		bf, err := newBasicFile(e.fsys, e.Path)
+		if err != nil {
+			Err(err)
+			return nil
+		}
+		found = append(found, bf)
+		return nil
+	}, func(err error) error {
+		errs = append(errs, err)
+		return nil
+	})
+	if err == nil && len(errs) > 0 {
+		err = errs
+	}
+	return found, err
+}
+
+// FindStream walks the tree rooted at root in the
+// background and sends each file that matches on the
+// returned channel, which is closed when the search
+// ends. Directories that cannot be read are sent as
+// results with Err set; with StopOnError, such an error
+// is the last result. Cancel ctx to stop the search
+// early.
+func FindStream(ctx context.Context, root string, match Predicate, opts FindOptions) <-chan FindResult {
+	results := make(chan FindResult, 64)
+
+	go func() {
+		defer close(results)
+		send := func(r FindResult) error {
+			select {
+			case results <- r:
+				return nil
+			case <-ctx.Done():
+				return SkipAll
+			}
+		}
+		err := findWalk(root, match, opts, func(e *FindEntry) error {
+			return send(FindResult{FindEntry: e})
+		}, func(err error) error {
+			return send(FindResult{Err: err})
+		})
+		if err != nil {
+			select {
+			case results <- FindResult{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,178 @@
+package gofile
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a.go", "b.txt", "big.bin", "sub/c.go", "sub/empty.txt", "sub/deeper/d.go", "void/")
+	writeFile := func(name string, size int, perm fs.FileMode, mtime time.Time) {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, make([]byte, size), perm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	writeFile("big.bin", 4096, 0755, old)
+	writeFile("sub/empty.txt", 0, 0600, time.Now())
+
+	name := func(pattern string) Predicate {
+		p, err := NameMatch(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name  string
+		match Predicate
+		opts  FindOptions
+		want  []string
+	}{
+		{"name", name("*.go"), FindOptions{}, []string{"a.go", "sub/c.go", "sub/deeper/d.go"}},
+		{"max depth", name("*.go"), FindOptions{MaxDepth: 2}, []string{"a.go", "sub/c.go"}},
+		{"min depth", name("*.go"), FindOptions{MinDepth: 2}, []string{"sub/c.go", "sub/deeper/d.go"}},
+		{"dirs", And(FileType(fs.ModeDir), Not(name("deeper"))), FindOptions{MinDepth: 1}, []string{"sub", "void"}},
+		{"large and old", And(FileType(0), LargerThan(1000), NotModifiedFor(30*24*time.Hour)), FindOptions{}, []string{"big.bin"}},
+		{"or", And(FileType(0), Or(name("*.txt"), PermAll(0100))), FindOptions{}, []string{"b.txt", "big.bin", "sub/empty.txt"}},
+		{"perm exact", And(FileType(0), PermExact(0600)), FindOptions{}, []string{"sub/empty.txt"}},
+		{"empty", Empty(), FindOptions{}, []string{"sub/empty.txt", "void"}},
+		{"size range", And(FileType(0), SizeRange(0, 5)), FindOptions{}, []string{"a.go", "b.txt", "sub/empty.txt"}},
+		{"smaller than", And(FileType(0), SmallerThan(1)), FindOptions{}, []string{"sub/empty.txt"}},
+		{"smaller than 0", SmallerThan(0), FindOptions{}, []string{}},
+		{"smaller than -1", SmallerThan(-1), FindOptions{}, []string{}},
+		{"owner", And(name("a.go"), Owner(os.Getuid())), FindOptions{}, []string{"a.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := Find(root, tt.match, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// BasicFile names are base names
+			got := []string{}
+			for _, f := range found {
+				got = append(got, baseName(f))
+			}
+			want := []string{}
+			for _, w := range tt.want {
+				want = append(want, filepath.Base(w))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Find() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	if _, err := NameMatch("[a-"); err == nil {
+		t.Error("NameMatch() expected error for malformed pattern")
+	}
+}
+
+func TestFind_errors(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/1", "b/2", "c/3")
+	fsys := NewFaultFS(nil, Fault{Op: FaultReadDir, Pattern: "b", Err: syscall.EACCES})
+
+	// as with find, the other directories are searched
+	found, err := Find(root, FileType(0), FindOptions{FS: fsys})
+	var errs FindErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(err, syscall.EACCES) {
+		t.Fatalf("Find() error = %v, want one EACCES error", err)
+	}
+	if len(found) != 2 {
+		t.Errorf("Find() found %d files, want 2", len(found))
+	}
+
+	found, err = Find(root, FileType(0), FindOptions{FS: fsys, StopOnError: true})
+	if errors.As(err, &errs) || !errors.Is(err, syscall.EACCES) {
+		t.Fatalf("Find() with StopOnError error = %v, want EACCES", err)
+	}
+	if len(found) != 1 {
+		t.Errorf("Find() with StopOnError found %d files, want 1", len(found))
+	}
+
+	var nerr int
+	for r := range FindStream(context.Background(), root, FileType(0), FindOptions{FS: fsys, Workers: 4}) {
+		if r.Err != nil {
+			nerr++
+		}
+	}
+	if nerr != 1 {
+		t.Errorf("FindStream() sent %d errors, want 1", nerr)
+	}
+}
+
+func TestNewerThanFileFS(t *testing.T) {
+	mem := NewMemFS()
+	now := time.Now()
+	for i, name := range []string{"/old", "/ref", "/new"} {
+		if err := WriteFileFS(mem, name, nil, NormalMode, false); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(time.Duration(i) * time.Hour)
+		if err := mem.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the reference file only exists in mem
+	newer, err := NewerThanFileFS(mem, "/ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := Find("/", And(FileType(0), newer), FindOptions{FS: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || baseName(found[0]) != "new" {
+		t.Errorf("Find() = %v, want [new]", found)
+	}
+
+	if _, err := NewerThanFileFS(mem, "/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("NewerThanFileFS() error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestFindStream(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/1", "a/2", "b/3", "c/4")
+
+	var got []string
+	for r := range FindStream(context.Background(), root, FileType(0), FindOptions{Workers: 4}) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		rel, _ := filepath.Rel(root, r.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	if want := []string{"a/1", "a/2", "b/3", "c/4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStream() = %v, want %v", got, want)
+	}
+
+	// stop after the first result
+	ctx, cancel := context.WithCancel(context.Background())
+	results := FindStream(ctx, root, nil, FindOptions{Ordered: true})
+	<-results
+	cancel()
+	for range results {
+	}
+}
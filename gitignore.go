@@ -0,0 +1,316 @@
+package gofile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultIgnoreFiles are the names of the ignore files
+// read in each directory by an IgnoreMatcher.
+var DefaultIgnoreFiles = []string{".gitignore", ".ignore"}
+
+// IgnoreMatcher matches paths against the rules of the
+// .gitignore files found in a directory tree, following
+// the semantics described in gitignore(5):
+//
+//   - blank lines and lines starting with '#' are skipped
+//   - a leading '!' negates a pattern, re-including files
+//   - a trailing '/' only matches directories
+//   - a pattern containing a '/' other than at the end is
+//     anchored to the directory of its ignore file
+//   - '**' matches any number of directories
+//   - rules in deeper ignore files take precedence, and
+//     files in an ignored directory cannot be re-included
+//
+// Ignore files are read lazily as directories are matched
+// and cached. An IgnoreMatcher is safe for concurrent use.
+type IgnoreMatcher struct {
+	root  string
+	files []string
+	repo  bool // root contains a .git directory
+
+	mu      sync.Mutex
+	rules   map[string][]ignoreRule // directory (relative to root) -> rules
+	ignored map[string]bool         // directory (relative to root) -> ignored
+}
+
+// ignoreRule is a single pattern from an ignore file.
+type ignoreRule struct {
+	pattern []string // slash separated segments; may include "**"
+	negate  bool
+	dirOnly bool
+}
+
+// NewIgnoreMatcher returns a matcher for the tree rooted
+// at root that reads the named ignore files in each
+// directory. If no names are given, DefaultIgnoreFiles
+// are used. If root contains a .git directory, the rules
+// in .git/info/exclude also apply and the .git directory
+// itself is always ignored.
+func NewIgnoreMatcher(root string, files ...string) *IgnoreMatcher {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	if len(files) == 0 {
+		files = DefaultIgnoreFiles
+	}
+	return &IgnoreMatcher{
+		root:    root,
+		files:   files,
+		repo:    IsDir(filepath.Join(root, ".git")),
+		rules:   make(map[string][]ignoreRule),
+		ignored: make(map[string]bool),
+	}
+}
+
+// FindIgnoreRoot returns the nearest directory at or
+// above dir that contains a .git directory. If there is
+// none, dir is returned.
+func FindIgnoreRoot(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; {
+		if IsDir(filepath.Join(d, ".git")) {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return abs
+		}
+		d = parent
+	}
+}
+
+// Root returns the root directory of the matcher.
+func (m *IgnoreMatcher) Root() string {
+	return m.root
+}
+
+// AddPatterns adds rules as if they were the last lines
+// of an ignore file in dir, which must be root or one
+// of its subdirectories.
+func (m *IgnoreMatcher) AddPatterns(dir string, patterns ...string) {
+	key, ok := m.rel(dir)
+	if !ok {
+		return
+	}
+	rules := m.dirRules(key)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, line := range patterns {
+		if r, ok := parseIgnoreRule(line); ok {
+			rules = append(rules, r)
+		}
+	}
+	m.rules[key] = rules
+	m.ignored = make(map[string]bool)
+}
+
+// Match reports whether the file at path is ignored.
+// The isDir argument reports whether path is a
+// directory. Paths outside of the root are never
+// ignored.
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	key, ok := m.rel(path)
+	if !ok || key == "" {
+		return false
+	}
+	segs := strings.Split(key, "/")
+
+	// the repository itself is never part of the tree,
+	// whatever the ignore files say
+	if m.repo && segs[0] == ".git" {
+		return true
+	}
+
+	// a path inside an ignored directory is ignored
+	for i := 1; i < len(segs); i++ {
+		if m.dirIgnored(segs[:i]) {
+			return true
+		}
+	}
+	return m.match(segs, isDir)
+}
+
+// rel returns path relative to the root, with forward
+// slashes, and reports whether path is inside the root.
+func (m *IgnoreMatcher) rel(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// dirIgnored reports whether the directory segs is
+// ignored by its own rules, caching the result.
+func (m *IgnoreMatcher) dirIgnored(segs []string) bool {
+	key := strings.Join(segs, "/")
+
+	m.mu.Lock()
+	ignored, ok := m.ignored[key]
+	m.mu.Unlock()
+	if ok {
+		return ignored
+	}
+
+	ignored = m.match(segs, true)
+
+	m.mu.Lock()
+	m.ignored[key] = ignored
+	m.mu.Unlock()
+	return ignored
+}
+
+// match applies the rules of the root and each directory
+// above segs, in order, to segs. The last matching rule
+// decides.
+func (m *IgnoreMatcher) match(segs []string, isDir bool) bool {
+	ignored := false
+	for depth := 0; depth < len(segs); depth++ {
+		for _, r := range m.dirRules(strings.Join(segs[:depth], "/")) {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			if matchSegments(r.pattern, segs[depth:]) {
+				ignored = !r.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// dirRules returns the rules from the ignore files in
+// the directory key, reading them on first use.
+func (m *IgnoreMatcher) dirRules(key string) []ignoreRule {
+	m.mu.Lock()
+	rules, ok := m.rules[key]
+	m.mu.Unlock()
+	if ok {
+		return rules
+	}
+
+	dir := filepath.Join(m.root, filepath.FromSlash(key))
+	if key == "" {
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, ".git", "info", "exclude"))...)
+	}
+	for _, name := range m.files {
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, name))...)
+	}
+
+	m.mu.Lock()
+	m.rules[key] = rules
+	m.mu.Unlock()
+	return rules
+}
+
+// readIgnoreFile returns the rules in the named file.
+// Missing or unreadable files have no rules.
+func readIgnoreFile(name string) []ignoreRule {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if r, ok := parseIgnoreRule(scanner.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		Err(NewGoFileError("unable to read ignore file", name, err))
+	}
+	return rules
+}
+
+// parseIgnoreRule parses one line of an ignore file. It
+// reports false for blank lines, comments and malformed
+// patterns.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	var r ignoreRule
+
+	line = strings.TrimSuffix(line, "\r")
+
+	// trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || line[0] == '#' {
+		return r, false
+	}
+	if line[0] == '!' {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return r, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	r.pattern = strings.Split(line, "/")
+	if !anchored {
+		r.pattern = append([]string{"**"}, r.pattern...)
+	}
+	for _, seg := range r.pattern {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return r, false
+		}
+	}
+	return r, true
+}
+
+// matchSegments reports whether the path segments in name
+// match the pattern segments. A "**" segment matches any
+// number of path segments; a trailing "**" matches one or
+// more, so that "dir/**" matches the contents of dir but
+// not dir itself.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
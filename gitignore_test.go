@@ -0,0 +1,137 @@
+package gofile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreMatcher_Match(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"node_modules/pkg/index.js",
+		"build/out.o",
+		"src/main.go",
+		"src/gen/types.go",
+		"src/docs/build/keep.txt",
+		"logs/a.log",
+		"logs/keep.log",
+		"deep/a/b/c.tmp",
+	)
+	writeFile := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), NormalMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(".gitignore", `# dependencies
+node_modules/
+/build
+*.log
+!keep.log
+deep/**/*.tmp
+\#literal
+trailing   
+`)
+	writeFile("src/.gitignore", "gen/\n!/docs/build\n")
+
+	m := NewIgnoreMatcher(root)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"node_modules/pkg/index.js", false, true},
+		{"node_modules", false, false}, // directory only pattern
+		{"build", true, true},
+		{"build/out.o", false, true},
+		{"src/docs/build", true, false}, // anchored to root, then re-included
+		{"src/main.go", false, false},
+		{"src/gen", true, true},
+		{"src/gen/types.go", false, true},
+		{"logs/a.log", false, true},
+		{"logs/keep.log", false, false},
+		{"deep/a/b/c.tmp", false, true},
+		{"deep/c.tmp", false, true},
+		{"#literal", false, true},
+		{"trailing", false, true},
+		{".gitignore", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := m.Match(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+
+	if m.Match(filepath.Dir(root), true) {
+		t.Error("Match() = true for path outside of root")
+	}
+}
+
+func TestIgnoreMatcher_gitDir(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, ".git/HEAD", ".git/info/exclude", "main.go")
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("!.git/\n"), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewIgnoreMatcher(root)
+	for _, tt := range []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{".git", true, true},
+		{".git/HEAD", false, true},
+		{"main.go", false, false},
+	} {
+		if got := m.Match(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	// only the .git directory of the root is excluded
+	other := t.TempDir()
+	makeTree(t, other, "vendor/.git/HEAD")
+	if NewIgnoreMatcher(other).Match(filepath.Join(other, "vendor", ".git"), true) {
+		t.Error("Match() = true for .git below a root that is not a repository")
+	}
+}
+
+func TestIgnoreMatcher_walk(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a.go", "a.log", "vendor/x.go", "sub/b.go", "sub/tmp/")
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\nvendor/\ntmp/\n"), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Walker{Ordered: true, Ignore: NewIgnoreMatcher(root)}
+	want := []string{".", ".gitignore", "a.go", "sub", "sub/b.go"}
+	if got := walkPaths(t, w, root, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() = %v, want %v", got, want)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	if _, err := w.CopyTree(root, dst); err != nil {
+		t.Fatal(err)
+	}
+	if got := walkPaths(t, &Walker{Ordered: true}, dst, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyTree() copied %v, want %v", got, want)
+	}
+
+	d, err := NewDIR(root, WithAlmostAll(true), WithGitIgnore(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := d.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || d.Summary().Ignored != 2 {
+		t.Errorf("List() = %d entries, %d ignored; want 3, 2", len(list), d.Summary().Ignored)
+	}
+}
@@ -0,0 +1,219 @@
+package gofile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Match reports whether name matches the glob pattern.
+// The pattern syntax is that of filepath.Match, with
+// these additions:
+//
+//	'**'        as a whole path segment, matches zero or
+//	            more directories
+//	'{a,b,c}'   matches any of the comma separated
+//	            alternatives, which may contain patterns
+//	            and may be nested
+//
+// Patterns and names use '/' as the separator. Special
+// characters may be escaped with '\'. The only possible
+// error is ErrBadPattern, when pattern is malformed.
+func Match(pattern, name string) (bool, error) {
+	patterns, err := expandBraces(pattern)
+	if err != nil {
+		return false, err
+	}
+	segs := strings.Split(name, "/")
+	for _, p := range patterns {
+		ps := strings.Split(p, "/")
+		if err := checkSegments(ps); err != nil {
+			return false, err
+		}
+		if matchGlobSegments(ps, segs) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Glob returns the names of all files matching pattern,
+// sorted and without duplicates, using the syntax of
+// Match. Symbolic links are not followed by '**'. As
+// with filepath.Glob, I/O errors are ignored and the
+// only possible error is ErrBadPattern.
+func Glob(pattern string) ([]string, error) {
+	patterns, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		dir := "."
+		if strings.HasPrefix(p, "/") {
+			dir = "/"
+			p = strings.TrimLeft(p, "/")
+		}
+		segs := strings.Split(p, "/")
+		if err := checkSegments(segs); err != nil {
+			return nil, err
+		}
+		globSegments(dir, segs, found)
+	}
+
+	matches := make([]string, 0, len(found))
+	for name := range found {
+		matches = append(matches, name)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// checkSegments returns ErrBadPattern if any of the
+// pattern segments is malformed.
+func checkSegments(segs []string) error {
+	for _, seg := range segs {
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return ErrBadPattern
+		}
+	}
+	return nil
+}
+
+// matchGlobSegments is matchSegments, except that a
+// trailing "**" also matches zero segments, so that
+// "dir/**" matches dir itself.
+func matchGlobSegments(pattern, name []string) bool {
+	if n := len(pattern); n > 0 && pattern[n-1] == "**" && matchSegments(pattern[:n-1], name) {
+		return true
+	}
+	return matchSegments(pattern, name)
+}
+
+// globSegments adds the files below dir matching the
+// pattern segments to found.
+func globSegments(dir string, segs []string, found map[string]bool) {
+	if len(segs) == 0 {
+		if _, err := os.Lstat(dir); err == nil {
+			found[dir] = true
+		}
+		return
+	}
+	seg, rest := segs[0], segs[1:]
+
+	switch {
+	case seg == "":
+		// repeated or trailing '/'
+		globSegments(dir, rest, found)
+	case seg == "**":
+		globSegments(dir, rest, found)
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			switch {
+			case e.IsDir():
+				globSegments(path, segs, found)
+			case len(rest) == 0:
+				found[path] = true
+			}
+		}
+	case !hasMeta(seg):
+		globSegments(filepath.Join(dir, unescape(seg)), rest, found)
+	default:
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if ok, _ := filepath.Match(seg, e.Name()); ok {
+				globSegments(filepath.Join(dir, e.Name()), rest, found)
+			}
+		}
+	}
+}
+
+// hasMeta reports whether s contains any of the special
+// characters of filepath.Match.
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+// unescape removes the '\' escapes from a pattern
+// without special characters.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// expandBraces returns the patterns produced by
+// expanding each brace expression in pattern, in
+// order. Braces inside character classes and escaped
+// braces are literal.
+func expandBraces(pattern string) ([]string, error) {
+	open, inClass := -1, false
+	depth := 0
+	var commas []int
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+			// a ']' directly after '[' or '[^' is literal
+			if i+1 < len(pattern) && pattern[i+1] == '^' {
+				i++
+			}
+			if i+1 < len(pattern) && pattern[i+1] == ']' {
+				i++
+			}
+		case c == '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}':
+			if depth == 0 {
+				return nil, ErrBadPattern
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			prefix, suffix := pattern[:open], pattern[i+1:]
+			start := open + 1
+			var patterns []string
+			for _, end := range append(commas, i) {
+				expanded, err := expandBraces(prefix + pattern[start:end] + suffix)
+				if err != nil {
+					return nil, err
+				}
+				patterns = append(patterns, expanded...)
+				start = end + 1
+			}
+			return patterns, nil
+		}
+	}
+	if depth > 0 {
+		return nil, ErrBadPattern
+	}
+	return []string{pattern}, nil
+}
@@ -0,0 +1,95 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+		wantErr bool
+	}{
+		{"*.go", "main.go", true, false},
+		{"*.go", "cmd/main.go", false, false},
+		{"**/*.go", "main.go", true, false},
+		{"**/*.go", "cmd/dir/main.go", true, false},
+		{"cmd/**", "cmd", true, false},
+		{"cmd/**", "cmd/dir/main.go", true, false},
+		{"cmd/**/main.go", "cmd/main.go", true, false},
+		{"cmd/**/main.go", "pkg/main.go", false, false},
+		{"*.{go,md}", "README.md", true, false},
+		{"*.{go,md}", "go.sum", false, false},
+		{"{cmd,pkg/{a,b}}/*.go", "pkg/b/x.go", true, false},
+		{"{cmd,pkg/{a,b}}/*.go", "pkg/c/x.go", false, false},
+		{"[a-c]?.txt", "b1.txt", true, false},
+		{"[{]*", "{x", true, false},
+		{`\{a,b\}`, "{a,b}", true, false},
+		{`\*`, "x", false, false},
+		{"{a,b", "a", false, true},
+		{"a}", "a", false, true},
+		{"[a-", "a", false, true},
+		{"{x,[a-}", "x", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.name, func(t *testing.T) {
+			got, err := Match(tt.pattern, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Match() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBadPattern) {
+				t.Errorf("Match() error = %v, want ErrBadPattern", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a.go", "b.md", "c.txt", "cmd/main.go", "cmd/dir/dir.go", "pkg/x/x.go", "pkg/x/x_test.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"a.go"}},
+		{"**/*.go", []string{"a.go", "cmd/dir/dir.go", "cmd/main.go", "pkg/x/x.go", "pkg/x/x_test.go"}},
+		{"*.{go,md}", []string{"a.go", "b.md"}},
+		{"{cmd,pkg}/**/*_test.go", []string{"pkg/x/x_test.go"}},
+		{"cmd/**", []string{"cmd", "cmd/dir", "cmd/dir/dir.go", "cmd/main.go"}},
+		{"**/x/*.go", []string{"pkg/x/x.go", "pkg/x/x_test.go"}},
+		{"**/**/main.go", []string{"cmd/main.go"}},
+		{"none/**", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Glob(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Glob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Glob("{a"); !errors.Is(err, ErrBadPattern) {
+		t.Errorf("Glob() error = %v, want ErrBadPattern", err)
+	}
+}
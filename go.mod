@@ -6,11 +6,11 @@ require (
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1 // indirect
 	github.com/skeptycal/errorlogger v0.5.0
+	golang.org/x/sys v0.0.0-20220405052023-b1e9470b6e64
 )
 
 require github.com/skeptycal/basicfile v0.0.0-20220405190439-d5f7ae669feb
 
 require (
 	github.com/stretchr/testify v1.7.1 // indirect
-	golang.org/x/sys v0.0.0-20220405052023-b1e9470b6e64 // indirect
 )
@@ -32,7 +32,13 @@ func IsDir(name string) bool {
 
 	// TODO: use basicfile
 	// return NewFileWithErr(name).IsDir()
-	fi, err := os.Stat(name)
+	return IsDirFS(OSFS{}, name)
+}
+
+// IsDirFS reports whether the named file in fsys
+// is a directory.
+func IsDirFS(fsys FileSystem, name string) bool {
+	fi, err := fsys.Stat(name)
 	if err != nil {
 		return false
 	}
@@ -46,8 +52,13 @@ func IsRegular(name string) bool {
 
 	// TODO: use basicfile
 	// return NewFileWithErr(name).IsRegular()
+	return IsRegularFS(OSFS{}, name)
+}
 
-	fi, err := os.Stat(name)
+// IsRegularFS reports whether the named file in
+// fsys is a regular file.
+func IsRegularFS(fsys FileSystem, name string) bool {
+	fi, err := fsys.Stat(name)
 	if err != nil {
 		return false
 	}
@@ -0,0 +1,104 @@
+package gofile
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// idCheckInterval is the least time between checks of
+// the user and group databases for changes.
+var idCheckInterval = time.Second
+
+// IDNames resolves user and group IDs to names from
+// /etc/passwd and /etc/group, without cgo. The
+// databases are read when first needed and read again
+// when they change. The zero value is ready to use and
+// an IDNames is safe for concurrent use.
+type IDNames struct {
+	mu     sync.Mutex
+	users  idCache
+	groups idCache
+}
+
+// idCache holds the names read from a database file
+// and the state of the file when it was read.
+type idCache struct {
+	file    string
+	names   map[int]string
+	size    int64
+	modTime time.Time
+	ino     uint64
+	checked time.Time
+}
+
+// defaultIDNames is used for directory listings.
+var defaultIDNames = &IDNames{}
+
+// UserName returns the name of the user with the ID
+// uid, or uid in decimal if there is none.
+func UserName(uid int) string { return defaultIDNames.User(uid) }
+
+// GroupName returns the name of the group with the ID
+// gid, or gid in decimal if there is none.
+func GroupName(gid int) string { return defaultIDNames.Group(gid) }
+
+// User returns the name of the user with the ID uid, or
+// uid in decimal if there is none.
+func (r *IDNames) User(uid int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users.lookup(passwdFile, uid)
+}
+
+// Group returns the name of the group with the ID gid,
+// or gid in decimal if there is none.
+func (r *IDNames) Group(gid int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.groups.lookup(groupFile, gid)
+}
+
+func (c *idCache) lookup(file string, id int) string {
+	c.refresh(file)
+	if name, ok := c.names[id]; ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+// refresh reads file again if it is not the file read
+// before or it has changed since. A file that cannot be
+// read has no names.
+func (c *idCache) refresh(file string) {
+	now := time.Now()
+	if file == c.file && c.names != nil && now.Sub(c.checked) < idCheckInterval {
+		return
+	}
+	c.checked = now
+
+	fi, err := os.Stat(file)
+	if err != nil {
+		c.file, c.names = file, map[int]string{}
+		c.size, c.modTime, c.ino = 0, time.Time{}, 0
+		return
+	}
+	var ino uint64
+	if st, ok := statSys(fi); ok {
+		ino = st.ino
+	}
+	if file == c.file && c.names != nil && fi.Size() == c.size && fi.ModTime().Equal(c.modTime) && ino == c.ino {
+		return
+	}
+
+	names := make(map[int]string)
+	entries, _ := readIDFile(file)
+	for _, e := range entries {
+		if _, ok := names[e.id]; !ok {
+			names[e.id] = e.name // the first name of an ID is used
+		}
+	}
+	c.file, c.names = file, names
+	c.size, c.modTime, c.ino = fi.Size(), fi.ModTime(), ino
+}
@@ -0,0 +1,86 @@
+package gofile
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIDNames(t *testing.T) {
+	withIDFiles(t, testPasswd, testGroup)
+	defer func(d time.Duration) { idCheckInterval = d }(idCheckInterval)
+	idCheckInterval = 0
+
+	var r IDNames
+	tests := []struct {
+		name string
+		fn   func(int) string
+		id   int
+		want string
+	}{
+		{"root", r.User, 0, "root"},
+		{"user", r.User, 1001, "app"},
+		{"numeric name", r.User, 2000, "1234"},
+		{"unknown user", r.User, 4242, "4242"},
+		{"group", r.Group, 1003, "deploy"},
+		{"unknown group", r.Group, 1001, "1001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.id); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// a changed database is read again
+	if err := os.WriteFile(passwdFile, []byte("renamed:x:1001:1002::/:/bin/sh\n"), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.User(1001); got != "renamed" {
+		t.Errorf("User() after change = %q, want renamed", got)
+	}
+	os.Remove(groupFile)
+	if got := r.Group(1003); got != "1003" {
+		t.Errorf("Group() after removal = %q, want 1003", got)
+	}
+}
+
+func TestDirList_formatOwner(t *testing.T) {
+	uid, gid := os.Getuid(), os.Getgid()
+	withIDFiles(t, fmt.Sprintf("me:x:%d:%d::/:/bin/sh\n", uid, gid), fmt.Sprintf("us:x:%d:\n", gid))
+	defer func(d time.Duration) { idCheckInterval = d }(idCheckInterval)
+	idCheckInterval = 0
+
+	fi, err := os.Lstat(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statSys(fi); !ok {
+		t.Skip("file owners are not available on this system")
+	}
+
+	tests := []struct {
+		name    string
+		options []DirOption
+		want    string
+	}{
+		{"default", nil, "me       us       "},
+		{"numeric", []DirOption{WithNumeric(true)}, fmt.Sprintf("%-8d %-8d ", uid, gid)},
+		{"owner only", []DirOption{WithGroup(false)}, "me       "},
+		{"author", []DirOption{WithOwner(false), WithAuthor(true)}, "us       me       "},
+		{"none", []DirOption{WithOwner(false), WithGroup(false)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &dirList{opts: defaultOptions}
+			for _, option := range append([]DirOption{WithOwner(true), WithGroup(true)}, tt.options...) {
+				option(&l.opts)
+			}
+			if got := l.formatOwner(fi); got != tt.want {
+				t.Errorf("formatOwner() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package gofile
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteTo writes the directory listing to w, one
+// entry per line.
+//
+// If color is enabled in the listing options and w
+// is a terminal, names are colored using the color
+// map set with SetColors or, if none was set, the
+// LS_COLORS environment variable.
+//
+// If the recursive option is set, the listing of
+// each subdirectory follows in its own section, as
+// with 'ls -R'.
+func (l *dirList) WriteTo(w io.Writer) (n int64, err error) {
+	if l.opts.recursive {
+		return l.writeRecursive(w)
+	}
+
+	list, err := l.List()
+	if err != nil {
+		return 0, err
+	}
+
+	colors := l.colorsFor(w)
+
+	dir := l.Path()
+	for _, f := range list {
+		nn, err := io.WriteString(w, l.formatEntry(filepath.Join(dir, baseName(f)), f, colors))
+		n += int64(nn)
+		if err != nil {
+			return n, NewGoFileError("unable to write directory listing", dir, err)
+		}
+	}
+	return n, nil
+}
+
+// colorsFor returns the color map to use for
+// output to w, or nil if output is not colored.
+func (l *dirList) colorsFor(w io.Writer) *LSColors {
+	if !l.opts.color || !isTerminal(w) {
+		return nil
+	}
+	if l.colors == nil {
+		l.colors = LSColorsFromEnv()
+	}
+	return l.colors
+}
+
+// formatName returns the display name for the file
+// at path, colored and classified as set in the
+// listing options. If colors is nil, no escape
+// sequences are written.
+func (l *dirList) formatName(path string, fi fs.FileInfo, colors *LSColors) string {
+	name := filepath.Base(path)
+	if colors != nil {
+		name = colors.Paint(name, colors.SGR(path, fi))
+	}
+	if l.opts.classify {
+		name += classifySuffix(fi.Mode(), l.opts.slash)
+	}
+	return name
+}
+
+// formatEntry returns a single line of a directory
+// listing for the file at path. If colors is nil,
+// no escape sequences are written.
+func (l *dirList) formatEntry(path string, fi fs.FileInfo, colors *LSColors) string {
+	name := l.formatName(path, fi, colors)
+
+	if l.opts.one {
+		return name + "\n"
+	}
+
+	layout := l.opts.timeStyle
+	if layout == "" {
+		layout = time.Stamp
+	}
+
+	return fmt.Sprintf("%v %s%7d %v %s\n", fi.Mode(), l.formatOwner(fi), fi.Size(), fi.ModTime().Format(layout), name)
+}
+
+// formatOwner returns the owner, group and author
+// columns of a long listing, as set in the listing
+// options, each followed by a space. IDs are shown
+// by name unless the numeric option is set; where
+// they are not known, "?" is shown.
+func (l *dirList) formatOwner(fi fs.FileInfo) string {
+	if !l.opts.owner && !l.opts.group && !l.opts.author {
+		return ""
+	}
+	st, ok := statSys(fi)
+	user, group := "?", "?"
+	if ok {
+		if l.opts.numeric {
+			user, group = strconv.Itoa(int(st.uid)), strconv.Itoa(int(st.gid))
+		} else {
+			user, group = UserName(int(st.uid)), GroupName(int(st.gid))
+		}
+	}
+
+	var b strings.Builder
+	if l.opts.owner {
+		fmt.Fprintf(&b, "%-8s ", user)
+	}
+	if l.opts.group {
+		fmt.Fprintf(&b, "%-8s ", group)
+	}
+	if l.opts.author {
+		// the author is the owner on Unix systems
+		fmt.Fprintf(&b, "%-8s ", user)
+	}
+	return b.String()
+}
+
+// classifySuffix returns the 'ls -F' indicator for
+// a file mode. Directories are marked with slash;
+// if slash is zero, '/' is used.
+func classifySuffix(mode fs.FileMode, slash byte) string {
+	switch {
+	case mode.IsDir():
+		if slash == 0 {
+			slash = '/'
+		}
+		return string(slash)
+	case mode&fs.ModeSymlink != 0:
+		return "@"
+	case mode&fs.ModeNamedPipe != 0:
+		return "|"
+	case mode&fs.ModeSocket != 0:
+		return "="
+	case mode.IsRegular() && mode&0111 != 0:
+		return "*"
+	}
+	return ""
+}
+
+// isTerminal reports whether w is a character
+// device such as a terminal. The TERM variable
+// "dumb" disables colored output.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	if strings.EqualFold(os.Getenv("TERM"), "dumb") {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,392 @@
+package gofile
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultLSColors is the LS_COLORS value used when the
+// environment does not provide one. It matches the
+// file type defaults of GNU dircolors.
+const DefaultLSColors = "rs=0:di=01;34:ln=01;36:mh=00:pi=40;33:so=01;35:do=01;35:bd=40;33;01:cd=40;33;01:or=40;31;01:mi=00:su=37;41:sg=30;43:ca=00:tw=30;42:ow=34;42:st=37;44:ex=01;32"
+
+// lsColorKeys maps dircolors database keywords to
+// the two letter indicators used in LS_COLORS.
+var lsColorKeys = map[string]string{
+	"NORMAL":                "no",
+	"NORM":                  "no",
+	"FILE":                  "fi",
+	"RESET":                 "rs",
+	"DIR":                   "di",
+	"LINK":                  "ln",
+	"LNK":                   "ln",
+	"SYMLINK":               "ln",
+	"MULTIHARDLINK":         "mh",
+	"FIFO":                  "pi",
+	"PIPE":                  "pi",
+	"SOCK":                  "so",
+	"DOOR":                  "do",
+	"BLK":                   "bd",
+	"BLOCK":                 "bd",
+	"CHR":                   "cd",
+	"CHAR":                  "cd",
+	"ORPHAN":                "or",
+	"MISSING":               "mi",
+	"SETUID":                "su",
+	"SETGID":                "sg",
+	"CAPABILITY":            "ca",
+	"STICKY_OTHER_WRITABLE": "tw",
+	"OTHER_WRITABLE":        "ow",
+	"STICKY":                "st",
+	"EXEC":                  "ex",
+	"LEFTCODE":              "lc",
+	"LEFT":                  "lc",
+	"RIGHTCODE":             "rc",
+	"RIGHT":                 "rc",
+	"ENDCODE":               "ec",
+	"END":                   "ec",
+}
+
+// lsColorIndicators is the set of valid LS_COLORS
+// file type indicators.
+var lsColorIndicators = map[string]bool{
+	"no": true, "fi": true, "rs": true, "di": true, "ln": true,
+	"mh": true, "pi": true, "so": true, "do": true, "bd": true,
+	"cd": true, "or": true, "mi": true, "su": true, "sg": true,
+	"ca": true, "tw": true, "ow": true, "st": true, "ex": true,
+	"lc": true, "rc": true, "ec": true, "cl": true,
+}
+
+type lsColorGlob struct {
+	pattern string
+	sgr     string
+}
+
+// LSColors maps file types and file name patterns
+// to ANSI SGR sequences as described by the
+// LS_COLORS environment variable and the dircolors
+// database format.
+type LSColors struct {
+	types    map[string]string // indicator -> SGR parameters
+	globs    []lsColorGlob     // in order of definition
+	lnTarget bool              // ln=target: color links as their target
+}
+
+// NewLSColors returns an empty color map.
+func NewLSColors() *LSColors {
+	return &LSColors{types: make(map[string]string)}
+}
+
+// LSColorsFromEnv returns the color map described by
+// the LS_COLORS environment variable. If LS_COLORS is
+// unset or cannot be parsed, DefaultLSColors is used.
+func LSColorsFromEnv() *LSColors {
+	if s, ok := os.LookupEnv("LS_COLORS"); ok && s != "" {
+		c, err := ParseLSColors(s)
+		if err == nil {
+			return c
+		}
+		Err(err)
+	}
+	c, _ := ParseLSColors(DefaultLSColors)
+	return c
+}
+
+// ParseLSColors parses a string in the format of the
+// LS_COLORS environment variable, e.g.
+//
+//	di=01;34:ln=01;36:*.tar=01;31
+//
+// Unknown indicators are ignored. Entries that are not
+// of the form key=value return an error wrapping ErrInvalid.
+func ParseLSColors(s string) (*LSColors, error) {
+	c := NewLSColors()
+	for _, entry := range strings.Split(s, ":") {
+		if entry == "" {
+			continue
+		}
+		i := strings.IndexByte(entry, '=')
+		if i < 1 {
+			return nil, NewGoFileError("invalid LS_COLORS entry", entry, ErrInvalid)
+		}
+		c.set(entry[:i], entry[i+1:])
+	}
+	return c, nil
+}
+
+// ParseDircolors parses a dircolors database as written
+// by 'dircolors --print-database'.
+//
+// Entries following TERM lines are only applied if one
+// of the TERM patterns matches term. If term is empty,
+// every entry is applied.
+func ParseDircolors(r io.Reader, term string) (*LSColors, error) {
+	c := NewLSColors()
+
+	matched := true // entries before any TERM line apply
+	inTerm := false // in a block of consecutive TERM lines
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, NewGoFileError("invalid dircolors line", line, ErrInvalid)
+		}
+		key, value := fields[0], fields[1]
+
+		switch strings.ToUpper(key) {
+		case "TERM":
+			if !inTerm {
+				matched = false
+			}
+			inTerm = true
+			if ok, _ := filepath.Match(value, term); ok || term == "" {
+				matched = true
+			}
+			continue
+		case "COLOR", "OPTIONS", "EIGHTBIT", "COLORTERM":
+			inTerm = false
+			continue
+		}
+		inTerm = false
+
+		if !matched {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(key, "*"):
+			// already a glob
+		case strings.HasPrefix(key, "."):
+			key = "*" + key
+		default:
+			ind, ok := lsColorKeys[strings.ToUpper(key)]
+			if !ok {
+				continue
+			}
+			key = ind
+		}
+
+		c.set(key, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, NewGoFileError("unable to read dircolors database", "", err)
+	}
+	return c, nil
+}
+
+// set records a single indicator or glob entry.
+func (c *LSColors) set(key, value string) {
+	if strings.HasPrefix(key, "*") {
+		c.globs = append(c.globs, lsColorGlob{pattern: key, sgr: value})
+		return
+	}
+	if !lsColorIndicators[key] {
+		return
+	}
+	if key == "ln" && value == "target" {
+		c.lnTarget = true
+		return
+	}
+	switch key {
+	case "lc", "rc", "ec":
+		value = unescapeSGR(value)
+	}
+	c.types[key] = value
+}
+
+// unescapeSGR expands the backslash and caret escapes
+// allowed in the lc, rc and ec entries, e.g. \e[ or ^[[
+func unescapeSGR(s string) string {
+	if !strings.ContainsAny(s, "\\^") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'e', 'E':
+				b.WriteByte(0x1b)
+			case 'a':
+				b.WriteByte('\a')
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := 0
+				j := i
+				for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+					v = v*8 + int(s[j]-'0')
+				}
+				b.WriteByte(byte(v))
+				i = j - 1
+			default:
+				b.WriteByte(s[i])
+			}
+		case ch == '^' && i+1 < len(s):
+			i++
+			if s[i] == '?' {
+				b.WriteByte(0x7f)
+			} else {
+				b.WriteByte(s[i] & 0x1f)
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// String returns the color map in LS_COLORS format.
+func (c *LSColors) String() string {
+	entries := make([]string, 0, len(c.types)+len(c.globs)+1)
+	for _, k := range []string{"rs", "no", "fi", "di", "ln", "mh", "pi", "so", "do", "bd", "cd", "or", "mi", "su", "sg", "ca", "tw", "ow", "st", "ex", "lc", "rc", "ec", "cl"} {
+		if v, ok := c.types[k]; ok {
+			entries = append(entries, k+"="+v)
+		}
+	}
+	if c.lnTarget {
+		entries = append(entries, "ln=target")
+	}
+	for _, g := range c.globs {
+		entries = append(entries, g.pattern+"="+g.sgr)
+	}
+	return strings.Join(entries, ":")
+}
+
+// matchGlob returns the SGR sequence for the last
+// glob entry that matches name.
+func (c *LSColors) matchGlob(name string) (string, bool) {
+	for i := len(c.globs) - 1; i >= 0; i-- {
+		g := c.globs[i]
+		pattern := g.pattern
+		// the common '*suffix' form is a simple suffix test
+		if !strings.ContainsAny(pattern[1:], "*?[\\") {
+			if strings.HasSuffix(name, pattern[1:]) {
+				return g.sgr, true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return g.sgr, true
+		}
+	}
+	return "", false
+}
+
+// SGR returns the SGR parameters (e.g. "01;34") used to
+// display the file at path with the given Lstat info.
+// If fi is nil, the file is treated as missing.
+//
+// An empty string is returned if no color applies.
+func (c *LSColors) SGR(path string, fi fs.FileInfo) string {
+	if fi == nil {
+		return c.types["mi"]
+	}
+
+	mode := fi.Mode()
+	if mode&fs.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			if v, ok := c.types["or"]; ok {
+				return v
+			}
+			return c.types["ln"]
+		}
+		if !c.lnTarget {
+			return c.types["ln"]
+		}
+		fi, mode = target, target.Mode()
+	}
+
+	switch {
+	case mode.IsDir():
+		sticky := mode&fs.ModeSticky != 0
+		writable := mode&0002 != 0
+		switch {
+		case sticky && writable && c.has("tw"):
+			return c.types["tw"]
+		case writable && c.has("ow"):
+			return c.types["ow"]
+		case sticky && c.has("st"):
+			return c.types["st"]
+		}
+		return c.types["di"]
+	case mode&fs.ModeNamedPipe != 0:
+		return c.types["pi"]
+	case mode&fs.ModeSocket != 0:
+		return c.types["so"]
+	case mode&fs.ModeDevice != 0 && mode&fs.ModeCharDevice != 0:
+		return c.types["cd"]
+	case mode&fs.ModeDevice != 0:
+		return c.types["bd"]
+	case mode&fs.ModeIrregular != 0:
+		return c.types["or"]
+	}
+
+	// regular file
+	switch {
+	case mode&fs.ModeSetuid != 0 && c.has("su"):
+		return c.types["su"]
+	case mode&fs.ModeSetgid != 0 && c.has("sg"):
+		return c.types["sg"]
+	case mode&0111 != 0 && c.has("ex"):
+		return c.types["ex"]
+	}
+	if v, ok := c.matchGlob(fi.Name()); ok {
+		return v
+	}
+	return c.types["fi"]
+}
+
+func (c *LSColors) has(key string) bool {
+	v, ok := c.types[key]
+	return ok && v != "" && v != "0" && v != "00"
+}
+
+// Paint wraps s in the escape sequences for the
+// given SGR parameters. If sgr is empty, s is
+// returned unchanged.
+func (c *LSColors) Paint(s, sgr string) string {
+	if sgr == "" {
+		return s
+	}
+	lc, ok := c.types["lc"]
+	if !ok {
+		lc = "\x1b["
+	}
+	rc, ok := c.types["rc"]
+	if !ok {
+		rc = "m"
+	}
+	ec, ok := c.types["ec"]
+	if !ok {
+		rs, ok := c.types["rs"]
+		if !ok {
+			rs = "0"
+		}
+		ec = lc + rs + rc
+	}
+	return lc + sgr + rc + s + ec
+}
+
+// Colorize returns the base name of path painted
+// with the color for the file.
+func (c *LSColors) Colorize(path string, fi fs.FileInfo) string {
+	return c.Paint(filepath.Base(path), c.SGR(path, fi))
+}
@@ -0,0 +1,106 @@
+package gofile
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeInfo is an fs.FileInfo used to test mode
+// dependent behavior without touching the disk.
+type fakeInfo struct {
+	name string
+	mode fs.FileMode
+	size int64
+}
+
+func (f fakeInfo) Name() string       { return f.name }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) Mode() fs.FileMode  { return f.mode }
+func (f fakeInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fakeInfo) Sys() interface{}   { return nil }
+
+const testLSColors = "di=01;34:ex=01;32:pi=40;33:ow=34;42:tw=30;42:su=37;41:*.tar=01;31:*.TAR=00;31:*README=04"
+
+func TestLSColors_SGR(t *testing.T) {
+	c, err := ParseLSColors(testLSColors)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		fi   fs.FileInfo
+		want string
+	}{
+		{"dir", fakeInfo{"dir", fs.ModeDir | 0755, 0}, "01;34"},
+		{"other writable dir", fakeInfo{"dir", fs.ModeDir | 0777, 0}, "34;42"},
+		{"sticky other writable dir", fakeInfo{"tmp", fs.ModeDir | fs.ModeSticky | 0777, 0}, "30;42"},
+		{"executable", fakeInfo{"run.sh", 0755, 0}, "01;32"},
+		{"setuid", fakeInfo{"sudo", fs.ModeSetuid | 0755, 0}, "37;41"},
+		{"pipe", fakeInfo{"fifo", fs.ModeNamedPipe | 0644, 0}, "40;33"},
+		{"tar", fakeInfo{"a.tar", 0644, 0}, "01;31"},
+		{"TAR", fakeInfo{"a.TAR", 0644, 0}, "00;31"},
+		{"suffix glob", fakeInfo{"README", 0644, 0}, "04"},
+		{"plain file", fakeInfo{"a.go", 0644, 0}, ""},
+		{"missing", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.SGR(tt.name, tt.fi); got != tt.want {
+				t.Errorf("SGR(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLSColors_invalid(t *testing.T) {
+	if _, err := ParseLSColors("di=01;34:bogus"); err == nil {
+		t.Error("ParseLSColors() expected error for entry without '='")
+	}
+}
+
+func TestParseDircolors(t *testing.T) {
+	db := `# comment
+TERM xterm*
+TERM screen
+DIR 01;34 # directories
+LINK 01;36
+EXEC 01;32
+.tar 01;31
+*.gz 01;31
+TERM dumb
+DIR 00
+`
+	tests := []struct {
+		term string
+		want string
+	}{
+		{"xterm-256color", "di=01;34:ln=01;36:ex=01;32:*.tar=01;31:*.gz=01;31"},
+		{"dumb", "di=00"},
+		{"vt100", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			c, err := ParseDircolors(strings.NewReader(db), tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.String(); got != tt.want {
+				t.Errorf("ParseDircolors(%q) = %q, want %q", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLSColors_Paint(t *testing.T) {
+	c, _ := ParseLSColors("rs=0")
+	if got, want := c.Paint("name", "01;34"), "\x1b[01;34mname\x1b[0m"; got != want {
+		t.Errorf("Paint() = %q, want %q", got, want)
+	}
+	if got := c.Paint("name", ""); got != "name" {
+		t.Errorf("Paint() with no color = %q, want %q", got, "name")
+	}
+}
@@ -0,0 +1,670 @@
+package gofile
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxSymlinks is the number of symbolic links followed
+// while resolving a path before giving up, as in Linux.
+const maxSymlinks = 40
+
+// MemFS is a FileSystem held in memory, for tests and
+// benchmarks that should not touch the disk. It supports
+// directories, regular files and symbolic links, with
+// modes and modification times. Permission bits are
+// recorded but not enforced.
+//
+// Relative names are relative to the root, "/". A
+// MemFS is safe for concurrent use.
+type MemFS struct {
+	mu   sync.Mutex
+	root *memNode
+}
+
+// memNode is a file, directory or symbolic link.
+type memNode struct {
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte              // contents of a regular file
+	target   string              // target of a symbolic link
+	children map[string]*memNode // entries of a directory
+}
+
+// NewMemFS returns an empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{root: newMemDir(DirMode)}
+}
+
+func newMemDir(perm fs.FileMode) *memNode {
+	return &memNode{
+		mode:     fs.ModeDir | perm&fs.ModePerm,
+		modTime:  time.Now(),
+		children: make(map[string]*memNode),
+	}
+}
+
+// memInfo is the fs.FileInfo of a memNode at the time
+// it was read.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (fi memInfo) Name() string       { return fi.name }
+func (fi memInfo) Size() int64        { return fi.size }
+func (fi memInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi memInfo) ModTime() time.Time { return fi.modTime }
+func (fi memInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi memInfo) Sys() interface{}   { return nil }
+
+func (n *memNode) info(name string) fs.FileInfo {
+	size := int64(len(n.data))
+	if n.mode&fs.ModeSymlink != 0 {
+		size = int64(len(n.target))
+	}
+	return memInfo{name: name, size: size, mode: n.mode, modTime: n.modTime}
+}
+
+// entries returns the entries of a directory node,
+// sorted by name.
+func (n *memNode) entries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(n.children))
+	for name, child := range n.children {
+		entries = append(entries, fs.FileInfoToDirEntry(child.info(name)))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+// splitPath returns the elements of the cleaned path
+// name; the root has none.
+func splitPath(name string) []string {
+	p := path.Clean("/" + filepath.ToSlash(name))
+	if p == "/" {
+		return nil
+	}
+	return strings.Split(p[1:], "/")
+}
+
+func memError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// lookup returns the node at name and its path with
+// symbolic links resolved. Links in the directories of
+// name are followed; a link at the end of name is
+// followed if follow is set. The caller holds m.mu.
+func (m *MemFS) lookup(op, name string, follow bool) (*memNode, string, error) {
+	parts := splitPath(name)
+	var resolved []string
+	node := m.root
+
+	for i, hops := 0, 0; i < len(parts); i++ {
+		if !node.mode.IsDir() {
+			return nil, "", memError(op, name, syscall.ENOTDIR)
+		}
+		child, ok := node.children[parts[i]]
+		if !ok {
+			return nil, "", memError(op, name, fs.ErrNotExist)
+		}
+
+		if child.mode&fs.ModeSymlink != 0 && (i < len(parts)-1 || follow) {
+			if hops++; hops > maxSymlinks {
+				return nil, "", memError(op, name, syscall.ELOOP)
+			}
+			// restart from the root with the link replaced
+			// by its target
+			target := child.target
+			if !path.IsAbs(target) {
+				target = path.Join("/", strings.Join(resolved, "/"), target)
+			}
+			parts = append(splitPath(target), parts[i+1:]...)
+			resolved = resolved[:0]
+			node = m.root
+			i = -1
+			continue
+		}
+
+		node = child
+		resolved = append(resolved, parts[i])
+	}
+	return node, "/" + strings.Join(resolved, "/"), nil
+}
+
+// lookupParent returns the directory containing name,
+// its resolved path and the base name of name. The
+// caller holds m.mu.
+func (m *MemFS) lookupParent(op, name string) (*memNode, string, string, error) {
+	parts := splitPath(name)
+	if len(parts) == 0 {
+		return nil, "", "", memError(op, name, fs.ErrInvalid)
+	}
+	dir, dirPath, err := m.lookup(op, strings.Join(parts[:len(parts)-1], "/"), true)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if !dir.mode.IsDir() {
+		return nil, "", "", memError(op, name, syscall.ENOTDIR)
+	}
+	return dir, dirPath, parts[len(parts)-1], nil
+}
+
+// createParent returns the directory in which a file
+// created as name is placed, and its base name there.
+// As with open(2), a dangling symbolic link is followed
+// and the file is created at its target, unless excl
+// is set. The caller holds m.mu.
+func (m *MemFS) createParent(name string, excl bool) (*memNode, string, error) {
+	for hops := 0; ; hops++ {
+		dir, dirPath, base, err := m.lookupParent("open", name)
+		if err != nil {
+			return nil, "", err
+		}
+		child, ok := dir.children[base]
+		if !ok {
+			return dir, base, nil
+		}
+		if child.mode&fs.ModeSymlink == 0 || excl {
+			return nil, "", memError("open", name, fs.ErrExist)
+		}
+		if hops >= maxSymlinks {
+			return nil, "", memError("open", name, syscall.ELOOP)
+		}
+		name = child.target
+		if !path.IsAbs(name) {
+			name = path.Join(dirPath, name)
+		}
+	}
+}
+
+func (m *MemFS) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	node, _, err := m.lookup("open", name, true)
+	switch {
+	case err == nil:
+		if flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+			return nil, memError("open", name, fs.ErrExist)
+		}
+		if node.mode.IsDir() && writable {
+			return nil, memError("open", name, syscall.EISDIR)
+		}
+		if flag&os.O_TRUNC != 0 && writable {
+			node.data = nil
+			node.modTime = time.Now()
+		}
+	case flag&os.O_CREATE != 0 && os.IsNotExist(err):
+		dir, base, err := m.createParent(name, flag&os.O_EXCL != 0)
+		if err != nil {
+			return nil, err
+		}
+		node = &memNode{mode: perm & fs.ModePerm, modTime: time.Now()}
+		dir.children[base] = node
+		dir.modTime = node.modTime
+	default:
+		return nil, err
+	}
+	return &memFile{fs: m, node: node, name: name, flag: flag}, nil
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	return m.stat("stat", name, true)
+}
+
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	return m.stat("lstat", name, false)
+}
+
+func (m *MemFS) stat(op, name string, follow bool) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, _, err := m.lookup(op, name, follow)
+	if err != nil {
+		return nil, err
+	}
+	base := path.Base(path.Clean("/" + filepath.ToSlash(name)))
+	return node.info(base), nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, _, err := m.lookup("readdirent", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, memError("readdirent", name, syscall.ENOTDIR)
+	}
+	return node.entries(), nil
+}
+
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, _, err := m.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if node.mode&fs.ModeSymlink == 0 {
+		return "", memError("readlink", name, fs.ErrInvalid)
+	}
+	return node.target, nil
+}
+
+func (m *MemFS) Mkdir(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mkdir(name, perm)
+}
+
+// mkdir creates the directory name. The caller
+// holds m.mu.
+func (m *MemFS) mkdir(name string, perm fs.FileMode) error {
+	dir, _, base, err := m.lookupParent("mkdir", name)
+	if err != nil {
+		if os.IsNotExist(err) || len(splitPath(name)) > 0 {
+			return err
+		}
+		return memError("mkdir", name, fs.ErrExist) // the root
+	}
+	if _, ok := dir.children[base]; ok {
+		return memError("mkdir", name, fs.ErrExist)
+	}
+	node := newMemDir(perm)
+	dir.children[base] = node
+	dir.modTime = node.modTime
+	return nil
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parts := splitPath(name)
+	for i := 1; i <= len(parts); i++ {
+		p := strings.Join(parts[:i], "/")
+		node, _, err := m.lookup("mkdir", p, true)
+		if err == nil {
+			if !node.mode.IsDir() {
+				return memError("mkdir", p, syscall.ENOTDIR)
+			}
+			continue
+		}
+		if err := m.mkdir(p, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, _, base, err := m.lookupParent("remove", name)
+	if err != nil {
+		return err
+	}
+	node, ok := dir.children[base]
+	if !ok {
+		return memError("remove", name, fs.ErrNotExist)
+	}
+	if len(node.children) > 0 {
+		return memError("remove", name, syscall.ENOTEMPTY)
+	}
+	delete(dir.children, base)
+	dir.modTime = time.Now()
+	return nil
+}
+
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(splitPath(name)) == 0 {
+		m.root.children = make(map[string]*memNode)
+		return nil
+	}
+	dir, _, base, err := m.lookupParent("unlinkat", name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if _, ok := dir.children[base]; ok {
+		delete(dir.children, base)
+		dir.modTime = time.Now()
+	}
+	return nil
+}
+
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldDir, oldDirPath, oldBase, err := m.lookupParent("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	node, ok := oldDir.children[oldBase]
+	if !ok {
+		return memError("rename", oldpath, fs.ErrNotExist)
+	}
+	newDir, newDirPath, newBase, err := m.lookupParent("rename", newpath)
+	if err != nil {
+		return err
+	}
+
+	// a directory cannot be moved inside itself
+	src := path.Join(oldDirPath, oldBase)
+	if node.mode.IsDir() && strings.HasPrefix(path.Join(newDirPath, newBase)+"/", src+"/") {
+		if src == path.Join(newDirPath, newBase) {
+			return nil
+		}
+		return memError("rename", oldpath, fs.ErrInvalid)
+	}
+
+	if existing, ok := newDir.children[newBase]; ok {
+		switch {
+		case existing == node:
+			return nil
+		case existing.mode.IsDir() && !node.mode.IsDir():
+			return memError("rename", newpath, syscall.EISDIR)
+		case !existing.mode.IsDir() && node.mode.IsDir():
+			return memError("rename", newpath, syscall.ENOTDIR)
+		case len(existing.children) > 0:
+			return memError("rename", newpath, syscall.ENOTEMPTY)
+		}
+	}
+
+	delete(oldDir.children, oldBase)
+	newDir.children[newBase] = node
+	now := time.Now()
+	oldDir.modTime, newDir.modTime = now, now
+	return nil
+}
+
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, _, base, err := m.lookupParent("symlink", newname)
+	if err != nil {
+		return err
+	}
+	if _, ok := dir.children[base]; ok {
+		return memError("symlink", newname, fs.ErrExist)
+	}
+	node := &memNode{mode: fs.ModeSymlink | fs.ModePerm, modTime: time.Now(), target: oldname}
+	dir.children[base] = node
+	dir.modTime = node.modTime
+	return nil
+}
+
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, _, err := m.lookup("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	const bits = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+	node.mode = node.mode&^bits | mode&bits
+	return nil
+}
+
+// Chtimes sets the modification time of the named
+// file; access times are not recorded.
+func (m *MemFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, _, err := m.lookup("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	node.modTime = mtime
+	return nil
+}
+
+// memFile is an open file in a MemFS.
+type memFile struct {
+	fs     *MemFS
+	node   *memNode
+	name   string
+	flag   int
+	offset int64
+	dir    []fs.DirEntry // entries not yet returned by ReadDir
+	dirOK  bool          // dir has been read
+	closed bool
+}
+
+// check returns an error if the file is closed or
+// does not allow the access. The caller holds f.fs.mu.
+func (f *memFile) check(op string, write bool) error {
+	switch {
+	case f.closed:
+		return memError(op, f.name, fs.ErrClosed)
+	case write && f.flag&(os.O_WRONLY|os.O_RDWR) == 0,
+		!write && f.flag&os.O_WRONLY != 0:
+		return memError(op, f.name, syscall.EBADF)
+	case f.node.mode.IsDir() && op != "readdirent" && op != "seek":
+		return memError(op, f.name, syscall.EISDIR)
+	}
+	return nil
+}
+
+func (f *memFile) Name() string { return f.name }
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return nil, memError("stat", f.name, fs.ErrClosed)
+	}
+	return f.node.info(path.Base(path.Clean("/" + filepath.ToSlash(f.name)))), nil
+}
+
+func (f *memFile) Read(b []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	n, err := f.readAt(b, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *memFile) ReadAt(b []byte, off int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, memError("readat", f.name, fs.ErrInvalid)
+	}
+	n, err := f.readAt(b, off)
+	if err == nil && n < len(b) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *memFile) readAt(b []byte, off int64) (int, error) {
+	if off >= int64(len(f.node.data)) {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	return copyBytes(b, f.node.data[off:]), nil
+}
+
+// copyBytes is the built in copy, which is shadowed
+// in this package by the file copy function.
+func copyBytes(dst, src []byte) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		dst[i] = src[i]
+	}
+	return n
+}
+
+func (f *memFile) Write(b []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	n := f.writeAt(b, f.offset)
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) WriteAt(b []byte, off int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if off < 0 || f.flag&os.O_APPEND != 0 {
+		return 0, memError("writeat", f.name, fs.ErrInvalid)
+	}
+	return f.writeAt(b, off), nil
+}
+
+func (f *memFile) writeAt(b []byte, off int64) int {
+	if end := off + int64(len(b)); end > int64(len(f.node.data)) {
+		f.resize(end)
+	}
+	copyBytes(f.node.data[off:], b)
+	f.node.modTime = time.Now()
+	return len(b)
+}
+
+// resize sets the length of the file data, padding
+// with zeros.
+func (f *memFile) resize(size int64) {
+	if size <= int64(cap(f.node.data)) {
+		old := len(f.node.data)
+		f.node.data = f.node.data[:size]
+		for i := old; i < len(f.node.data); i++ {
+			f.node.data[i] = 0
+		}
+		return
+	}
+	data := make([]byte, size, size+size/4)
+	copyBytes(data, f.node.data)
+	f.node.data = data
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return 0, memError("seek", f.name, fs.ErrClosed)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	default:
+		return 0, memError("seek", f.name, fs.ErrInvalid)
+	}
+	if offset < 0 {
+		return 0, memError("seek", f.name, fs.ErrInvalid)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return nil, memError("readdirent", f.name, fs.ErrClosed)
+	}
+	if !f.node.mode.IsDir() {
+		return nil, memError("readdirent", f.name, syscall.ENOTDIR)
+	}
+	if !f.dirOK {
+		f.dir, f.dirOK = f.node.entries(), true
+	}
+
+	if n <= 0 || n > len(f.dir) {
+		if n > 0 && len(f.dir) == 0 {
+			return nil, io.EOF
+		}
+		n = len(f.dir)
+	}
+	entries := f.dir[:n:n]
+	f.dir = f.dir[n:]
+	return entries, nil
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return memError("sync", f.name, fs.ErrClosed)
+	}
+	return nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if err := f.check("truncate", true); err != nil {
+		return err
+	}
+	if size < 0 {
+		return memError("truncate", f.name, fs.ErrInvalid)
+	}
+	f.resize(size)
+	f.node.modTime = time.Now()
+	return nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.closed {
+		return memError("close", f.name, fs.ErrClosed)
+	}
+	f.closed = true
+	return nil
+}
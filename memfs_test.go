@@ -0,0 +1,112 @@
+package gofile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestMemFS(t *testing.T) {
+	fsys := NewMemFS()
+	if err := fsys.Mkdir("/work", DirMode); err != nil {
+		t.Fatal(err)
+	}
+	testFileSystem(t, fsys, "/work")
+}
+
+func TestMemFSErrors(t *testing.T) {
+	fsys := NewMemFS()
+	if err := WriteFileFS(fsys, "file", []byte("data"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	fsys.MkdirAll("dir/sub", DirMode)
+	fsys.Symlink("loop", "loop")
+	fsys.Symlink("/dir", "dirlink")
+
+	tests := []struct {
+		name string
+		fn   func() error
+		want error
+	}{
+		{"missing", func() error { _, err := fsys.Stat("missing"); return err }, fs.ErrNotExist},
+		{"not a directory", func() error { _, err := fsys.Stat("file/x"); return err }, syscall.ENOTDIR},
+		{"symlink loop", func() error { _, err := fsys.Stat("loop"); return err }, syscall.ELOOP},
+		{"link in path", func() error { _, err := fsys.Stat("dirlink/sub"); return err }, nil},
+		{"write directory", func() error { _, err := CreateFileFS(fsys, "dir", NormalMode, false); return err }, syscall.EISDIR},
+		{"remove non-empty", func() error { return fsys.Remove("dir") }, syscall.ENOTEMPTY},
+		{"rename into itself", func() error { return fsys.Rename("dir", "dir/sub/dir") }, fs.ErrInvalid},
+		{"rename over directory", func() error { return fsys.Rename("file", "dir") }, syscall.EISDIR},
+		{"remove all missing", func() error { return fsys.RemoveAll("missing/x") }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemFSCreateDanglingLink(t *testing.T) {
+	// MemFS follows a dangling link on create as the OS does
+	for name, root := range map[string]string{"MemFS": "/", "OSFS": t.TempDir()} {
+		t.Run(name, func(t *testing.T) {
+			fsys := FileSystem(OSFS{})
+			if name == "MemFS" {
+				fsys = NewMemFS()
+			}
+			join := func(name string) string { return filepath.Join(root, name) }
+			if err := fsys.Mkdir(join("dir"), DirMode); err != nil {
+				t.Fatal(err)
+			}
+			fsys.Symlink("dir/target", join("link"))
+			fsys.Symlink(join("link"), join("chain"))
+
+			if err := WriteFileFS(fsys, join("chain"), []byte("data"), NormalMode, false); err != nil {
+				t.Fatal(err)
+			}
+			if fi, err := fsys.Lstat(join("link")); err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+				t.Fatalf("Lstat(link) = %v, %v; want a symbolic link", fi, err)
+			}
+			if b, err := ReadFileFS(fsys, join("dir/target")); err != nil || string(b) != "data" {
+				t.Errorf("ReadFileFS(dir/target) = %q, %v; want %q", b, err, "data")
+			}
+
+			fsys.Symlink("dir/other", join("excl"))
+			if _, err := fsys.OpenFile(join("excl"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, NormalMode); !errors.Is(err, fs.ErrExist) {
+				t.Errorf("OpenFile(O_EXCL) error = %v, want fs.ErrExist", err)
+			}
+		})
+	}
+}
+
+func TestMemFSConcurrent(t *testing.T) {
+	fsys := NewMemFS()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dir := fmt.Sprintf("/d%d", i%2)
+			name := fmt.Sprintf("%s/f%d", dir, i)
+			fsys.MkdirAll(dir, DirMode)
+			for j := 0; j < 50; j++ {
+				if err := WriteFileFS(fsys, name, []byte(name), NormalMode, false); err != nil {
+					t.Error(err)
+					return
+				}
+				if b, err := ReadFileFS(fsys, name); err != nil || string(b) != name {
+					t.Errorf("ReadFileFS(%s) = %q, %v", name, b, err)
+					return
+				}
+				fsys.ReadDir(dir)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,608 @@
+package gofile
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// OverlayFS is a copy-on-write FileSystem. Files are
+// read from the Upper layer, or else from the Base
+// layer, which is never changed until Commit. A file
+// of the base layer is copied into the upper layer
+// before it is modified, and removing a file records
+// a whiteout that hides it in the base layer.
+//
+// Symbolic links are resolved within the layer that
+// holds them. The upper layer may be a MemFS, or a
+// BasePathFS for a scratch directory. Names in the
+// host file system are made absolute, so that the
+// same names are used in both layers.
+type OverlayFS struct {
+	Base  FileSystem
+	Upper FileSystem
+
+	mu        sync.Mutex
+	whiteouts map[string]bool // base paths hidden, with their contents
+}
+
+// NewOverlayFS returns an OverlayFS over base, or the
+// host file system if base is nil, that writes to
+// upper, or to a new MemFS if upper is nil.
+func NewOverlayFS(base, upper FileSystem) *OverlayFS {
+	if upper == nil {
+		upper = NewMemFS()
+	}
+	return &OverlayFS{
+		Base:      fsOrOS(base),
+		Upper:     upper,
+		whiteouts: make(map[string]bool),
+	}
+}
+
+// key returns the name of the named file in both
+// layers.
+func (o *OverlayFS) key(name string) string {
+	if isOS(o.Base) {
+		if abs, err := filepath.Abs(name); err == nil {
+			return abs
+		}
+	}
+	return filepath.Join(string(filepath.Separator), name)
+}
+
+// hidden reports whether key is hidden in the base
+// layer by a whiteout of it or a parent directory.
+func (o *OverlayFS) hidden(key string) bool {
+	for p := key; ; p = filepath.Dir(p) {
+		if o.whiteouts[p] {
+			return true
+		}
+		if p == filepath.Dir(p) {
+			return false
+		}
+	}
+}
+
+// inBase reports whether key is present in the base
+// layer, hidden or not.
+func (o *OverlayFS) inBase(key string) bool {
+	_, err := o.Base.Lstat(key)
+	return err == nil
+}
+
+// stat returns the file information of key and
+// whether it is in the upper layer.
+func (o *OverlayFS) stat(op, key string, follow bool) (fs.FileInfo, bool, error) {
+	lstat := FileSystem.Lstat
+	if follow {
+		lstat = FileSystem.Stat
+	}
+	fi, err := lstat(o.Upper, key)
+	if err == nil {
+		return fi, true, nil
+	}
+	if !errors.Is(err, ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+		return nil, false, err
+	}
+	if o.hidden(key) {
+		return nil, false, &fs.PathError{Op: op, Path: key, Err: ErrNotExist}
+	}
+	fi, err = lstat(o.Base, key)
+	return fi, false, err
+}
+
+// readDir returns the entries of the directory key,
+// merged from both layers.
+func (o *OverlayFS) readDir(key string) ([]fs.DirEntry, error) {
+	fi, _, err := o.stat("readdirent", key, true)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &fs.PathError{Op: "readdirent", Path: key, Err: syscall.ENOTDIR}
+	}
+
+	var entries []fs.DirEntry
+	seen := make(map[string]bool)
+	if upper, err := o.Upper.ReadDir(key); err == nil {
+		for _, e := range upper {
+			entries = append(entries, e)
+			seen[e.Name()] = true
+		}
+	}
+	if !o.hidden(key) {
+		base, err := o.Base.ReadDir(key)
+		if err != nil && !errors.Is(err, ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+			return nil, err
+		}
+		for _, e := range base {
+			if !seen[e.Name()] && !o.whiteouts[filepath.Join(key, e.Name())] {
+				entries = append(entries, e)
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// copyUp copies key from the base layer into the upper
+// layer, with its parent directories, unless it is
+// there already.
+func (o *OverlayFS) copyUp(key string) error {
+	if _, err := o.Upper.Lstat(key); err == nil {
+		return nil
+	}
+	if o.hidden(key) {
+		return &fs.PathError{Op: "copyup", Path: key, Err: ErrNotExist}
+	}
+	fi, err := o.Base.Lstat(key)
+	if err != nil {
+		return err
+	}
+	if err := o.copyUpDir(filepath.Dir(key)); err != nil {
+		return err
+	}
+	return transfer(o.Base, o.Upper, key, fi)
+}
+
+// copyUpDir copies the directory dir into the upper
+// layer. The root is always present.
+func (o *OverlayFS) copyUpDir(dir string) error {
+	if dir == filepath.Dir(dir) {
+		return nil
+	}
+	return o.copyUp(dir)
+}
+
+// copyUpTree copies key and, if it is a directory, its
+// contents into the upper layer.
+func (o *OverlayFS) copyUpTree(key string) error {
+	if err := o.copyUp(key); err != nil {
+		return err
+	}
+	fi, err := o.Upper.Lstat(key)
+	if err != nil || !fi.IsDir() {
+		return err
+	}
+	entries, err := o.readDir(key)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := o.copyUpTree(filepath.Join(key, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transfer copies the file name, described by fi, from
+// src to dst. Directories are created without their
+// contents.
+func transfer(src, dst FileSystem, name string, fi fs.FileInfo) error {
+	switch {
+	case fi.IsDir():
+		if err := dst.Mkdir(name, fi.Mode().Perm()); err != nil {
+			return NewGoFileError("unable to create directory", name, err)
+		}
+	case fi.Mode()&fs.ModeSymlink != 0:
+		target, err := src.Readlink(name)
+		if err != nil {
+			return NewGoFileError("unable to read symbolic link", name, err)
+		}
+		if err := dst.Symlink(target, name); err != nil {
+			return NewGoFileError("unable to create symbolic link", name, err)
+		}
+		return nil
+	case fi.Mode().IsRegular():
+		if err := transferData(src, dst, name, fi.Mode().Perm()); err != nil {
+			return err
+		}
+	default:
+		return NewGoFileError("cannot copy special file", name, ErrInvalid)
+	}
+
+	// the mode may have been limited by the umask
+	if err := dst.Chmod(name, fi.Mode()); err != nil {
+		return NewGoFileError("unable to set file mode", name, err)
+	}
+	if err := dst.Chtimes(name, fi.ModTime(), fi.ModTime()); err != nil {
+		return NewGoFileError("unable to set file times", name, err)
+	}
+	return nil
+}
+
+func transferData(src, dst FileSystem, name string, perm fs.FileMode) error {
+	in, err := src.Open(name)
+	if err != nil {
+		return NewGoFileError("unable to open source file", name, err)
+	}
+	defer in.Close()
+
+	out, err := dst.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return NewGoFileError("unable to create destination file", name, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return NewGoFileError("unable to copy file", name, err)
+	}
+	if err := out.Close(); err != nil {
+		return NewGoFileError("unable to close destination file", name, err)
+	}
+	return nil
+}
+
+func (o *OverlayFS) Open(name string) (File, error) {
+	return o.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens the named file in the upper layer if
+// it may be written, after copying it there.
+func (o *OverlayFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	layer := o.Upper
+	_, inUpper, err := o.stat("open", key, true)
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		if !inUpper {
+			layer = o.Base
+		}
+	} else {
+		switch {
+		case err == nil && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+			return nil, &fs.PathError{Op: "open", Path: name, Err: ErrExist}
+		case err == nil:
+			err = o.copyUp(key)
+		case errors.Is(err, ErrNotExist) && flag&os.O_CREATE != 0:
+			err = o.copyUpDir(filepath.Dir(key))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := layer.OpenFile(key, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &overlayFile{File: f, fsys: o, name: name, key: key}, nil
+}
+
+func (o *OverlayFS) Stat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fi, _, err := o.stat("stat", o.key(name), true)
+	return fi, err
+}
+
+func (o *OverlayFS) Lstat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fi, _, err := o.stat("lstat", o.key(name), false)
+	return fi, err
+}
+
+func (o *OverlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(o.key(name))
+}
+
+func (o *OverlayFS) Readlink(name string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	if _, inUpper, err := o.stat("readlink", key, false); err != nil {
+		return "", err
+	} else if inUpper {
+		return o.Upper.Readlink(key)
+	}
+	return o.Base.Readlink(key)
+}
+
+func (o *OverlayFS) Mkdir(name string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	if _, _, err := o.stat("mkdir", key, false); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: ErrExist}
+	}
+	if err := o.copyUpDir(filepath.Dir(key)); err != nil {
+		return err
+	}
+	return o.Upper.Mkdir(key, perm)
+}
+
+func (o *OverlayFS) MkdirAll(name string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	if fi, _, err := o.stat("mkdir", key, true); err == nil {
+		if !fi.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+		}
+		return nil
+	}
+
+	// copy up the deepest directory present
+	for p := filepath.Dir(key); p != filepath.Dir(p); p = filepath.Dir(p) {
+		if _, _, err := o.stat("mkdir", p, true); err == nil {
+			if err := o.copyUp(p); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return o.Upper.MkdirAll(key, perm)
+}
+
+func (o *OverlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	fi, inUpper, err := o.stat("remove", key, false)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		entries, err := o.readDir(key)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+	}
+
+	if inUpper {
+		if err := o.Upper.Remove(key); err != nil {
+			return err
+		}
+	}
+	if o.inBase(key) {
+		o.whiteouts[key] = true
+	}
+	return nil
+}
+
+func (o *OverlayFS) RemoveAll(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	if _, _, err := o.stat("unlinkat", key, false); errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err := o.Upper.RemoveAll(key); err != nil {
+		return err
+	}
+	if o.inBase(key) {
+		o.whiteouts[key] = true
+	}
+	return nil
+}
+
+// Rename copies oldpath and its contents into the upper
+// layer and renames it there.
+func (o *OverlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	oldKey, newKey := o.key(oldpath), o.key(newpath)
+	if _, _, err := o.stat("rename", oldKey, false); err != nil {
+		return err
+	}
+	if oldKey == newKey {
+		return nil
+	}
+
+	// the target is replaced in the upper layer
+	if fi, _, err := o.stat("rename", newKey, false); err == nil {
+		if fi.IsDir() {
+			if entries, err := o.readDir(newKey); err != nil {
+				return err
+			} else if len(entries) > 0 {
+				return &fs.PathError{Op: "rename", Path: newpath, Err: syscall.ENOTEMPTY}
+			}
+		}
+		if err := o.copyUp(newKey); err != nil {
+			return err
+		}
+	}
+
+	if err := o.copyUpTree(oldKey); err != nil {
+		return err
+	}
+	if err := o.copyUpDir(filepath.Dir(newKey)); err != nil {
+		return err
+	}
+	if err := o.Upper.Rename(oldKey, newKey); err != nil {
+		return err
+	}
+	for _, key := range []string{oldKey, newKey} {
+		if o.inBase(key) {
+			o.whiteouts[key] = true
+		}
+	}
+	return nil
+}
+
+func (o *OverlayFS) Symlink(oldname, newname string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(newname)
+	if _, _, err := o.stat("symlink", key, false); err == nil {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: ErrExist}
+	}
+	if err := o.copyUpDir(filepath.Dir(key)); err != nil {
+		return err
+	}
+	return o.Upper.Symlink(oldname, key)
+}
+
+func (o *OverlayFS) Chmod(name string, mode fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	if err := o.copyUp(key); err != nil {
+		return err
+	}
+	return o.Upper.Chmod(key, mode)
+}
+
+func (o *OverlayFS) Chtimes(name string, atime, mtime time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := o.key(name)
+	if err := o.copyUp(key); err != nil {
+		return err
+	}
+	return o.Upper.Chtimes(key, atime, mtime)
+}
+
+// Commit applies the changes in the upper layer to the
+// base layer, then discards them. If an error occurs,
+// the changes made so far remain in both layers.
+func (o *OverlayFS) Commit() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	paths := make([]string, 0, len(o.whiteouts))
+	for p := range o.whiteouts {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := o.Base.RemoveAll(p); err != nil {
+			return NewGoFileError("unable to remove file", p, err)
+		}
+	}
+
+	if err := o.commitDir(string(filepath.Separator)); err != nil {
+		return err
+	}
+	return o.discard()
+}
+
+// commitDir copies the contents of the directory dir in
+// the upper layer to the base layer.
+func (o *OverlayFS) commitDir(dir string) error {
+	entries, err := o.Upper.ReadDir(dir)
+	if err != nil {
+		return NewGoFileError("unable to read directory", dir, err)
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		fi, err := o.Upper.Lstat(p)
+		if err != nil {
+			return NewGoFileError("unable to stat file", p, err)
+		}
+
+		base, err := o.Base.Lstat(p)
+		if err == nil && (fi.Mode().Type() != base.Mode().Type() || fi.Mode()&fs.ModeSymlink != 0) {
+			if err := o.Base.RemoveAll(p); err != nil {
+				return NewGoFileError("unable to replace file", p, err)
+			}
+			err = ErrNotExist
+		}
+
+		switch {
+		case !fi.IsDir():
+			err = transfer(o.Upper, o.Base, p, fi)
+		case err != nil:
+			err = transfer(o.Upper, o.Base, p, fi)
+		case base.Mode() != fi.Mode():
+			err = o.Base.Chmod(p, fi.Mode())
+		default:
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			if err := o.commitDir(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Discard removes the changes in the upper layer.
+func (o *OverlayFS) Discard() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.discard()
+}
+
+func (o *OverlayFS) discard() error {
+	root := string(filepath.Separator)
+	entries, err := o.Upper.ReadDir(root)
+	if err != nil {
+		return NewGoFileError("unable to read upper layer", root, err)
+	}
+	for _, e := range entries {
+		p := filepath.Join(root, e.Name())
+		if err := o.Upper.RemoveAll(p); err != nil {
+			return NewGoFileError("unable to remove file", p, err)
+		}
+	}
+	o.whiteouts = make(map[string]bool)
+	return nil
+}
+
+// overlayFile is a file opened in an OverlayFS. The
+// entries of a directory are merged from both layers.
+type overlayFile struct {
+	File
+	fsys    *OverlayFS
+	name    string
+	key     string
+	entries []fs.DirEntry // entries not yet returned by ReadDir
+	read    bool          // entries has been read
+}
+
+func (f *overlayFile) Name() string { return f.name }
+
+func (f *overlayFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.read {
+		f.fsys.mu.Lock()
+		entries, err := f.fsys.readDir(f.key)
+		f.fsys.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		f.entries, f.read = entries, true
+	}
+
+	if n <= 0 || n > len(f.entries) {
+		if n > 0 && len(f.entries) == 0 {
+			return nil, io.EOF
+		}
+		n = len(f.entries)
+	}
+	entries := f.entries[:n:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOverlayFS(t *testing.T) {
+	testFileSystem(t, NewOverlayFS(NewMemFS(), nil), "/")
+
+	base := NewMemFS()
+	base.MkdirAll("/dir/sub", DirMode)
+	WriteFileFS(base, "/dir/a", []byte("a"), NormalMode, false)
+	WriteFileFS(base, "/dir/b", []byte("b"), NormalMode, false)
+	WriteFileFS(base, "/dir/sub/c", []byte("c"), NormalMode, false)
+	o := NewOverlayFS(base, nil)
+
+	names := func(fsys FileSystem, dir string) []string {
+		t.Helper()
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	if err := WriteFileFS(o, "/dir/a", []byte("A"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileFS(o, "/dir/new", []byte("new"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Remove("/dir/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Rename("/dir/sub", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the base layer is unchanged
+	if b, _ := ReadFileFS(base, "/dir/a"); string(b) != "a" {
+		t.Errorf("base /dir/a = %q, want a", b)
+	}
+	if want := []string{"a", "b", "sub"}; !reflect.DeepEqual(names(base, "/dir"), want) {
+		t.Errorf("base ReadDir() = %v, want %v", names(base, "/dir"), want)
+	}
+
+	// the overlay shows the changes
+	if b, _ := ReadFileFS(o, "/dir/a"); string(b) != "A" {
+		t.Errorf("overlay /dir/a = %q, want A", b)
+	}
+	if want := []string{"a", "new"}; !reflect.DeepEqual(names(o, "/dir"), want) {
+		t.Errorf("overlay ReadDir() = %v, want %v", names(o, "/dir"), want)
+	}
+	if b, _ := ReadFileFS(o, "/moved/c"); string(b) != "c" {
+		t.Errorf("overlay /moved/c = %q, want c", b)
+	}
+	if _, err := o.Stat("/dir/b"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() of removed file error = %v, want ErrNotExist", err)
+	}
+
+	// a directory created over a removed one is empty
+	if err := o.RemoveAll("/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Mkdir("/dir/sub", DirMode); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(o, "/dir/sub"); len(got) != 0 {
+		t.Errorf("ReadDir() of recreated directory = %v, want none", got)
+	}
+
+	if err := o.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "new", "sub"}; !reflect.DeepEqual(names(base, "/dir"), want) {
+		t.Errorf("ReadDir() after Commit = %v, want %v", names(base, "/dir"), want)
+	}
+	if got := names(base, "/dir/sub"); len(got) != 0 {
+		t.Errorf("ReadDir() of recreated directory after Commit = %v, want none", got)
+	}
+	if b, _ := ReadFileFS(base, "/dir/a"); string(b) != "A" {
+		t.Errorf("base /dir/a after Commit = %q, want A", b)
+	}
+	if got := names(o.Upper, "/"); len(got) != 0 {
+		t.Errorf("upper layer after Commit = %v, want empty", got)
+	}
+
+	WriteFileFS(o, "/dir/discarded", nil, NormalMode, false)
+	if err := o.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if ExistsFS(o, "/dir/discarded") || ExistsFS(base, "/dir/discarded") {
+		t.Error("file present after Discard")
+	}
+}
+
+func TestOverlayFSHost(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "a", "sub/", "sub/b")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	o := NewOverlayFS(nil, NewBasePathFS(nil, t.TempDir()))
+	if err := WriteFileFS(o, "sub/b", []byte("changed"), NormalMode, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "sub/b")); string(b) != "sub/b" {
+		t.Errorf("sub/b before Commit = %q", b)
+	}
+	if err := o.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "sub/b")); string(b) != "changed" {
+		t.Errorf("sub/b after Commit = %q, want changed", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Errorf("a after Commit error = %v, want not exist", err)
+	}
+}
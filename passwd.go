@@ -0,0 +1,130 @@
+package gofile
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The user and group databases, read without cgo, so
+// that names are resolved in static binaries. Users and
+// groups from other sources, such as LDAP, are not seen.
+var (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+)
+
+// idEntry is a user or group from the databases.
+type idEntry struct {
+	name string
+	id   int
+	gid  int // primary group of a user
+}
+
+// parseIDFile parses entries in the format of
+// /etc/passwd or /etc/group: colon separated fields,
+// with the name first and the ID third. For users,
+// the primary group is the fourth. Comments, blank
+// lines and malformed lines are skipped.
+func parseIDFile(r io.Reader) ([]idEntry, error) {
+	var entries []idEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '+' || line[0] == '-' {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		e := idEntry{name: fields[0], id: id, gid: -1}
+		if len(fields) > 3 {
+			if gid, err := strconv.Atoi(fields[3]); err == nil {
+				e.gid = gid
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// readIDFile returns the entries of the named database.
+func readIDFile(name string) ([]idEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to open user database", name, err)
+	}
+	defer f.Close()
+
+	entries, err := parseIDFile(f)
+	if err != nil {
+		return nil, NewGoFileError("unable to read user database", name, err)
+	}
+	return entries, nil
+}
+
+// lookupID returns the entry for name in the database
+// file. A decimal number not found as a name is taken
+// as an ID, with an unknown primary group.
+func lookupID(file, name string) (idEntry, error) {
+	entries, err := readIDFile(file)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return idEntry{}, err
+	}
+	for _, e := range entries {
+		if e.name == name {
+			return e, nil
+		}
+	}
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return idEntry{name: name, id: id, gid: -1}, nil
+	}
+	return idEntry{}, NewGoFileError("unknown user or group", name, ErrInvalid)
+}
+
+// ParseOwner parses an owner as accepted by chown(1),
+// "user", "user:group", ":group" or "user:", and
+// returns the user and group IDs, or -1 for those not
+// given. "user:" selects the login group of the user.
+// Names are resolved from /etc/passwd and /etc/group;
+// decimal IDs are also accepted.
+func ParseOwner(owner string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	user, group, hasGroup := strings.Cut(owner, ":")
+	if user == "" && group == "" {
+		if owner == "" {
+			return -1, -1, NewGoFileError("missing owner", owner, ErrInvalid)
+		}
+		return -1, -1, nil // ":" changes nothing
+	}
+
+	if user != "" {
+		u, err := lookupID(passwdFile, user)
+		if err != nil {
+			return -1, -1, err
+		}
+		uid = u.id
+		if hasGroup && group == "" {
+			if u.gid < 0 {
+				return -1, -1, NewGoFileError("unknown login group of user", user, ErrInvalid)
+			}
+			gid = u.gid
+		}
+	}
+	if group != "" {
+		g, err := lookupID(groupFile, group)
+		if err != nil {
+			return -1, -1, err
+		}
+		gid = g.id
+	}
+	return uid, gid, nil
+}
@@ -0,0 +1,76 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testPasswd = `# comment
+root:x:0:0:root:/root:/bin/bash
+app:x:1001:1002:App:/srv/app:/usr/sbin/nologin
+1234:x:2000:2000::/:/bin/false
++nis::::::
+broken
+`
+	testGroup = `root:x:0:
+app:x:1002:
+deploy:x:1003:app
+`
+)
+
+// withIDFiles uses the given contents as the user and
+// group databases for the rest of the test.
+func withIDFiles(t *testing.T, passwd, group string) {
+	t.Helper()
+	dir := t.TempDir()
+	oldPasswd, oldGroup := passwdFile, groupFile
+	passwdFile, groupFile = filepath.Join(dir, "passwd"), filepath.Join(dir, "group")
+	t.Cleanup(func() { passwdFile, groupFile = oldPasswd, oldGroup })
+	if err := os.WriteFile(passwdFile, []byte(passwd), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(groupFile, []byte(group), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	withIDFiles(t, testPasswd, testGroup)
+
+	tests := []struct {
+		owner    string
+		uid, gid int
+		wantErr  bool
+	}{
+		{"app", 1001, -1, false},
+		{"app:deploy", 1001, 1003, false},
+		{"app:", 1001, 1002, false},
+		{":deploy", -1, 1003, false},
+		{"root:root", 0, 0, false},
+		{"42:43", 42, 43, false},
+		{"1234", 2000, -1, false}, // names before IDs
+		{":", -1, -1, false},
+		{"", -1, -1, true},
+		{"nobody", -1, -1, true},
+		{"app:nogroup", -1, -1, true},
+		{"42:", -1, -1, true},
+		{"-1", -1, -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			uid, gid, err := ParseOwner(tt.owner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOwner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("ParseOwner() error = %v, want ErrInvalid", err)
+			}
+			if uid != tt.uid || gid != tt.gid {
+				t.Errorf("ParseOwner() = %d, %d, want %d, %d", uid, gid, tt.uid, tt.gid)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package gofile
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ReadOnlyFS is a FileSystem that reads from another
+// FileSystem and rejects every change to it. Mutating
+// calls, and writes to files opened from it, return a
+// GoFileError wrapping ErrPermission.
+type ReadOnlyFS struct {
+	FS FileSystem
+}
+
+// NewReadOnlyFS returns a ReadOnlyFS for fsys, or the
+// host file system if fsys is nil.
+func NewReadOnlyFS(fsys FileSystem) *ReadOnlyFS {
+	return &ReadOnlyFS{FS: fsOrOS(fsys)}
+}
+
+// readOnly returns the error for the operation op on
+// the named file.
+func readOnly(op, name string) error {
+	return NewGoFileError(op+": read-only file system", name, ErrPermission)
+}
+
+func (r *ReadOnlyFS) Open(name string) (File, error) {
+	return r.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile opens the named file for reading; any flag
+// that allows writing, creating or truncating the file
+// is rejected.
+func (r *ReadOnlyFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, readOnly("open", name)
+	}
+	f, err := r.FS.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return readOnlyFile{f}, nil
+}
+
+func (r *ReadOnlyFS) Stat(name string) (fs.FileInfo, error)      { return r.FS.Stat(name) }
+func (r *ReadOnlyFS) Lstat(name string) (fs.FileInfo, error)     { return r.FS.Lstat(name) }
+func (r *ReadOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) { return r.FS.ReadDir(name) }
+func (r *ReadOnlyFS) Readlink(name string) (string, error)       { return r.FS.Readlink(name) }
+
+func (r *ReadOnlyFS) Mkdir(name string, perm fs.FileMode) error    { return readOnly("mkdir", name) }
+func (r *ReadOnlyFS) MkdirAll(path string, perm fs.FileMode) error { return readOnly("mkdir", path) }
+func (r *ReadOnlyFS) Remove(name string) error                     { return readOnly("remove", name) }
+func (r *ReadOnlyFS) RemoveAll(path string) error                  { return readOnly("remove", path) }
+func (r *ReadOnlyFS) Rename(oldpath, newpath string) error         { return readOnly("rename", oldpath) }
+func (r *ReadOnlyFS) Symlink(oldname, newname string) error        { return readOnly("symlink", newname) }
+func (r *ReadOnlyFS) Chmod(name string, mode fs.FileMode) error    { return readOnly("chmod", name) }
+func (r *ReadOnlyFS) Chtimes(name string, atime, mtime time.Time) error {
+	return readOnly("chtimes", name)
+}
+
+// readOnlyFile is a file opened in a ReadOnlyFS.
+type readOnlyFile struct {
+	File
+}
+
+func (f readOnlyFile) Write(b []byte) (int, error) { return 0, readOnly("write", f.Name()) }
+func (f readOnlyFile) WriteAt(b []byte, off int64) (int, error) {
+	return 0, readOnly("write", f.Name())
+}
+func (f readOnlyFile) Truncate(size int64) error { return readOnly("truncate", f.Name()) }
@@ -0,0 +1,65 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyFS(t *testing.T) {
+	mem := NewMemFS()
+	mem.MkdirAll("/dir", DirMode)
+	WriteFileFS(mem, "/dir/file", []byte("data"), NormalMode, false)
+	fsys := NewReadOnlyFS(mem)
+
+	if b, err := ReadFileFS(fsys, "/dir/file"); err != nil || string(b) != "data" {
+		t.Fatalf("ReadFileFS() = %q, %v, want data", b, err)
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"create", func() error { _, err := CreateFileFS(fsys, "/dir/new", NormalMode, false); return err }},
+		{"append", func() error { _, err := fsys.OpenFile("/dir/file", os.O_WRONLY|os.O_APPEND, 0); return err }},
+		{"write file", func() error { return WriteFileFS(fsys, "/dir/file", nil, NormalMode, false) }},
+		{"copy", func() error { _, err := CopyFS(fsys, "/dir/file", "/dir/copy"); return err }},
+		{"mkdir", func() error { return fsys.Mkdir("/dir/sub", DirMode) }},
+		{"mkdir all", func() error { return fsys.MkdirAll("/dir/a/b", DirMode) }},
+		{"remove", func() error { return fsys.Remove("/dir/file") }},
+		{"remove all", func() error { return fsys.RemoveAll("/dir") }},
+		{"rename", func() error { return fsys.Rename("/dir/file", "/dir/moved") }},
+		{"symlink", func() error { return fsys.Symlink("file", "/dir/link") }},
+		{"chmod", func() error { return fsys.Chmod("/dir/file", 0600) }},
+		{"chtimes", func() error { return fsys.Chtimes("/dir/file", time.Now(), time.Now()) }},
+		{"write", func() error {
+			f, err := fsys.Open("/dir/file")
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.Write([]byte("x"))
+			return err
+		}},
+		{"truncate", func() error {
+			f, err := fsys.Open("/dir/file")
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return f.Truncate(0)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); !errors.Is(err, ErrPermission) {
+				t.Errorf("error = %v, want ErrPermission", err)
+			}
+		})
+	}
+
+	if b, err := ReadFileFS(mem, "/dir/file"); err != nil || string(b) != "data" {
+		t.Errorf("file changed to %q, %v", b, err)
+	}
+}
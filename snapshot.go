@@ -0,0 +1,349 @@
+package gofile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SnapshotEntry records the state of one file in a
+// Snapshot.
+type SnapshotEntry struct {
+	Path    string      `json:"path"` // relative to the root, '/' separated
+	Type    string      `json:"type"` // see fileTypeName
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"` // permission and special bits
+	ModTime time.Time   `json:"mtime"`
+	Dev     uint64      `json:"dev,omitempty"` // device of the file system holding the file
+	Inode   uint64      `json:"inode,omitempty"`
+	Hash    string      `json:"hash,omitempty"` // hex SHA-256 of regular files
+}
+
+// Snapshot records the entries of a directory tree at
+// a point in time. It may be saved as JSON and compared
+// with a later snapshot using Diff.
+type Snapshot struct {
+	Root    string          `json:"root"`
+	Time    time.Time       `json:"time"`
+	Entries []SnapshotEntry `json:"entries"` // sorted by path
+}
+
+// SnapshotOptions are the options for TakeSnapshot.
+type SnapshotOptions struct {
+	// Hash records the SHA-256 hash of each regular
+	// file, so that changes that keep the size and
+	// modification time, and renames across file
+	// systems, are detected.
+	Hash bool
+
+	// Workers is the number of directories read
+	// concurrently; see Walker.
+	Workers int
+
+	// Ignore, if not nil, skips matching files.
+	Ignore *IgnoreMatcher
+
+	// FS is where the tree to record is read from;
+	// nil means OSFS.
+	FS FileSystem
+}
+
+// TakeSnapshot walks the tree rooted at root and
+// records its entries, not including root itself.
+// Symbolic links are recorded but not followed.
+func TakeSnapshot(root string, opts SnapshotOptions) (*Snapshot, error) {
+	root = filepath.Clean(root)
+	s := &Snapshot{Root: root, Time: time.Now()}
+
+	var mu sync.Mutex
+	w := &Walker{Workers: opts.Workers, Ignore: opts.Ignore, FS: opts.FS}
+	err := w.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return NewGoFileError("unable to read directory for snapshot", path, err)
+		}
+		if path == root {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return NewGoFileError("unable to stat file for snapshot", path, err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return NewGoFileError("unable to find path for snapshot", path, err)
+		}
+
+		e := SnapshotEntry{
+			Path:    filepath.ToSlash(rel),
+			Type:    fileTypeName(fi.Mode()),
+			Size:    fi.Size(),
+			Mode:    fi.Mode() &^ fs.ModeType,
+			ModTime: fi.ModTime(),
+		}
+		if st, ok := statSys(fi); ok {
+			e.Dev, e.Inode = st.dev, st.ino
+		}
+		if opts.Hash && fi.Mode().IsRegular() {
+			if e.Hash, err = hashFile(w.fs(), path); err != nil {
+				return err
+			}
+		}
+
+		mu.Lock()
+		s.Entries = append(s.Entries, e)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(s.Entries, func(i, j int) bool { return s.Entries[i].Path < s.Entries[j].Path })
+	return s, nil
+}
+
+// LoadSnapshot reads a snapshot saved as JSON.
+func LoadSnapshot(name string) (*Snapshot, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to open snapshot", name, err)
+	}
+	defer f.Close()
+
+	s := &Snapshot{}
+	if err := json.NewDecoder(f).Decode(s); err != nil {
+		return nil, NewGoFileError("unable to decode snapshot", name, err)
+	}
+	return s, nil
+}
+
+// Save writes the snapshot as JSON to the named file.
+func (s *Snapshot) Save(name string) error {
+	f, err := CreateFile(name, NormalMode, false)
+	if err != nil {
+		return err
+	}
+	if _, err := s.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return NewGoFileError("unable to close snapshot", name, err)
+	}
+	return nil
+}
+
+// WriteTo writes the snapshot as indented JSON to w.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return 0, NewGoFileError("unable to encode snapshot", s.Root, err)
+	}
+	n, err := w.Write(append(b, '\n'))
+	if err != nil {
+		return int64(n), NewGoFileError("unable to write snapshot", s.Root, err)
+	}
+	return int64(n), nil
+}
+
+// fileTypeName returns the name of the file type
+// recorded in a SnapshotEntry.
+func fileTypeName(m fs.FileMode) string {
+	switch {
+	case m.IsRegular():
+		return "file"
+	case m.IsDir():
+		return "dir"
+	case m&fs.ModeSymlink != 0:
+		return "symlink"
+	case m&fs.ModeNamedPipe != 0:
+		return "pipe"
+	case m&fs.ModeSocket != 0:
+		return "socket"
+	case m&fs.ModeCharDevice != 0:
+		return "chardevice"
+	case m&fs.ModeDevice != 0:
+		return "device"
+	}
+	return "irregular"
+}
+
+// hashFile returns the hex SHA-256 hash of the
+// contents of the named file in fsys.
+func hashFile(fsys FileSystem, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", NewGoFileError("unable to open file for hashing", name, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", NewGoFileError("unable to hash file", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChangeKind is the kind of change between two
+// snapshots.
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Modified
+	Renamed
+	PermChanged
+)
+
+var changeNames = map[ChangeKind]string{
+	1: "Added",
+	2: "Removed",
+	3: "Modified",
+	4: "Renamed",
+	5: "PermChanged",
+}
+
+func (k ChangeKind) String() string {
+	return changeNames[k]
+}
+
+// Change is a difference between two snapshots. Old
+// is nil for added entries and New is nil for removed
+// entries.
+type Change struct {
+	Kind    ChangeKind
+	Path    string // path in the newer snapshot, or the removed path
+	OldPath string // path in the older snapshot, if renamed
+	Old     *SnapshotEntry
+	New     *SnapshotEntry
+}
+
+func (c Change) String() string {
+	if c.Kind == Renamed {
+		return c.Kind.String() + " " + c.OldPath + " -> " + c.Path
+	}
+	return c.Kind.String() + " " + c.Path
+}
+
+// Diff returns the changes from snapshot a to the
+// later snapshot b, sorted by path.
+//
+// An entry removed from a and added to b is reported
+// as renamed if both have the same device and inode, or
+// the same hash, and the same type and contents, so
+// that reused inodes are not mistaken for renames. An
+// entry may be both modified, or renamed, and have
+// changed permissions. Changes to
+// the size and modification time of directories are
+// not reported.
+func Diff(a, b Snapshot) []Change {
+	older := make(map[string]*SnapshotEntry, len(a.Entries))
+	for i := range a.Entries {
+		older[a.Entries[i].Path] = &a.Entries[i]
+	}
+	newer := make(map[string]*SnapshotEntry, len(b.Entries))
+	for i := range b.Entries {
+		newer[b.Entries[i].Path] = &b.Entries[i]
+	}
+
+	var changes []Change
+	var removed, added []*SnapshotEntry
+
+	for i := range a.Entries {
+		o := &a.Entries[i]
+		n, ok := newer[o.Path]
+		if !ok {
+			removed = append(removed, o)
+			continue
+		}
+		if contentChanged(o, n) {
+			changes = append(changes, Change{Kind: Modified, Path: n.Path, Old: o, New: n})
+		}
+		if o.Mode != n.Mode {
+			changes = append(changes, Change{Kind: PermChanged, Path: n.Path, Old: o, New: n})
+		}
+	}
+	for i := range b.Entries {
+		if _, ok := older[b.Entries[i].Path]; !ok {
+			added = append(added, &b.Entries[i])
+		}
+	}
+
+	// match removed entries with added entries by device
+	// and inode, then by hash; inode numbers are only
+	// unique within a file system
+	byInode := make(map[[2]uint64][]*SnapshotEntry)
+	byHash := make(map[string][]*SnapshotEntry)
+	for _, n := range added {
+		if n.Inode != 0 {
+			key := [2]uint64{n.Dev, n.Inode}
+			byInode[key] = append(byInode[key], n)
+		}
+		if n.Hash != "" {
+			byHash[n.Hash] = append(byHash[n.Hash], n)
+		}
+	}
+	matched := make(map[*SnapshotEntry]bool)
+	take := func(candidates []*SnapshotEntry, o *SnapshotEntry) *SnapshotEntry {
+		for _, n := range candidates {
+			if !matched[n] && !contentChanged(o, n) {
+				matched[n] = true
+				return n
+			}
+		}
+		return nil
+	}
+
+	for _, o := range removed {
+		var n *SnapshotEntry
+		if o.Inode != 0 {
+			n = take(byInode[[2]uint64{o.Dev, o.Inode}], o)
+		}
+		if n == nil && o.Hash != "" {
+			n = take(byHash[o.Hash], o)
+		}
+		if n == nil {
+			changes = append(changes, Change{Kind: Removed, Path: o.Path, Old: o})
+			continue
+		}
+		changes = append(changes, Change{Kind: Renamed, Path: n.Path, OldPath: o.Path, Old: o, New: n})
+		if o.Mode != n.Mode {
+			changes = append(changes, Change{Kind: PermChanged, Path: n.Path, OldPath: o.Path, Old: o, New: n})
+		}
+	}
+	for _, n := range added {
+		if !matched[n] {
+			changes = append(changes, Change{Kind: Added, Path: n.Path, New: n})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+		return changes[i].Kind < changes[j].Kind
+	})
+	return changes
+}
+
+// contentChanged reports whether the type or contents
+// of an entry differ between snapshots.
+func contentChanged(o, n *SnapshotEntry) bool {
+	switch {
+	case o.Type != n.Type:
+		return true
+	case o.Type == "dir":
+		return false
+	case o.Hash != "" && n.Hash != "":
+		return o.Hash != n.Hash
+	}
+	return o.Size != n.Size || !o.ModTime.Equal(n.ModTime)
+}
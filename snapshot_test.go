@@ -0,0 +1,97 @@
+package gofile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "keep", "edit", "gone", "moved", "copied", "chmod", "chmove", "dir/")
+
+	before, err := TakeSnapshot(root, SnapshotOptions{Hash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before.Entries) != 8 || before.Entries[0].Path != "chmod" || before.Entries[3].Type != "dir" {
+		t.Fatalf("TakeSnapshot() entries = %+v", before.Entries)
+	}
+
+	// save and reload to check the JSON round trip
+	saved := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := before.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Diff(*before, *loaded)) != 0 || loaded.Entries[1].Hash == "" {
+		t.Fatalf("LoadSnapshot() = %+v, want %+v", loaded, before)
+	}
+
+	join := func(name string) string { return filepath.Join(root, name) }
+	later := time.Now().Add(time.Hour)
+	if err := os.WriteFile(join("edit"), []byte("changed"), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(join("edit"), later, later)
+	os.Remove(join("gone"))
+	os.Rename(join("moved"), join("dir/moved"))
+	// a copy keeps the contents but not the inode
+	os.Remove(join("copied"))
+	os.WriteFile(join("copy"), []byte("copied"), NormalMode)
+	os.Chmod(join("chmod"), 0600)
+	os.Rename(join("chmove"), join("chmoved"))
+	os.Chmod(join("chmoved"), 0600)
+	os.WriteFile(join("new"), []byte("new file"), NormalMode)
+
+	after, err := TakeSnapshot(root, SnapshotOptions{Hash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range Diff(*before, *after) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"PermChanged chmod",
+		"Renamed chmove -> chmoved",
+		"PermChanged chmoved",
+		"Renamed copied -> copy",
+		"Renamed moved -> dir/moved",
+		"Modified edit",
+		"Removed gone",
+		"Added new",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+}
+
+func TestDiff_device(t *testing.T) {
+	mtime := time.Now()
+	entry := func(path string, dev uint64) SnapshotEntry {
+		return SnapshotEntry{Path: path, Type: "file", Size: 1, Mode: NormalMode, ModTime: mtime, Dev: dev, Inode: 42}
+	}
+
+	// the same inode on another file system is another file
+	a := Snapshot{Entries: []SnapshotEntry{entry("a", 1)}}
+	b := Snapshot{Entries: []SnapshotEntry{entry("b", 2)}}
+	var got []string
+	for _, c := range Diff(a, b) {
+		got = append(got, c.String())
+	}
+	if want := []string{"Removed a", "Added b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+
+	b.Entries[0].Dev = 1
+	if changes := Diff(a, b); len(changes) != 1 || changes[0].Kind != Renamed {
+		t.Errorf("Diff() = %v, want a rename", changes)
+	}
+}
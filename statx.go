@@ -0,0 +1,75 @@
+package gofile
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ExtStat is the extended file information returned by
+// StatX. Fields the system does not provide are zero;
+// in particular, Btime is only known on Linux with
+// statx(2) and file systems that record it.
+type ExtStat struct {
+	Name      string // base name of the file
+	Size      int64
+	Mode      fs.FileMode
+	Ino       uint64
+	Dev       uint64 // device containing the file
+	Nlink     uint64
+	Uid       uint32
+	Gid       uint32
+	Blocks    int64 // 512 byte blocks allocated
+	BlockSize int64 // preferred block size for I/O
+	MountID   uint64
+
+	Atime time.Time // last access
+	Mtime time.Time // last modification
+	Ctime time.Time // last status change
+	Btime time.Time // creation
+}
+
+// StatX returns the extended file information of the
+// named file, following symbolic links.
+func StatX(name string) (*ExtStat, error) {
+	return statX(name, true)
+}
+
+// LstatX returns the extended file information of the
+// named file. If the file is a symbolic link, the
+// information describes the link.
+func LstatX(name string) (*ExtStat, error) {
+	return statX(name, false)
+}
+
+// statXFallback fills an ExtStat from os.Stat and, where
+// it is available, the system stat data; the creation
+// time and mount ID are not known.
+func statXFallback(name string, follow bool) (*ExtStat, error) {
+	stat := os.Lstat
+	if follow {
+		stat = os.Stat
+	}
+	fi, err := stat(name)
+	if err != nil {
+		return nil, NewGoFileError("unable to stat file", name, err)
+	}
+	st := &ExtStat{
+		Name:  fi.Name(),
+		Size:  fi.Size(),
+		Mode:  fi.Mode(),
+		Mtime: fi.ModTime(),
+	}
+	if sys, ok := statSys(fi); ok {
+		st.Ino = sys.ino
+		st.Dev = sys.dev
+		st.Nlink = sys.nlink
+		st.Uid = sys.uid
+		st.Gid = sys.gid
+		st.Blocks = sys.blocks
+		st.BlockSize = sys.blksize
+		st.Atime = accessTime(fi)
+		st.Ctime = changeTime(fi)
+	}
+	return st, nil
+}
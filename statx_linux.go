@@ -0,0 +1,85 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// statX uses statx(2), or stat(2) where statx is not
+// available.
+func statX(name string, follow bool) (*ExtStat, error) {
+	flags := unix.AT_STATX_SYNC_AS_STAT
+	if !follow {
+		flags |= unix.AT_SYMLINK_NOFOLLOW
+	}
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, name, flags, unix.STATX_BASIC_STATS|unix.STATX_BTIME|unix.STATX_MNT_ID, &stx)
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+		return statXFallback(name, follow)
+	}
+	if err != nil {
+		return nil, NewGoFileError("unable to stat file", name, err)
+	}
+
+	st := &ExtStat{
+		Name:      filepath.Base(name),
+		Size:      int64(stx.Size),
+		Mode:      rawFileMode(uint32(stx.Mode)),
+		Ino:       stx.Ino,
+		Dev:       unix.Mkdev(stx.Dev_major, stx.Dev_minor),
+		Nlink:     uint64(stx.Nlink),
+		Uid:       stx.Uid,
+		Gid:       stx.Gid,
+		Blocks:    int64(stx.Blocks),
+		BlockSize: int64(stx.Blksize),
+		Atime:     statxTime(stx.Atime),
+		Mtime:     statxTime(stx.Mtime),
+		Ctime:     statxTime(stx.Ctime),
+	}
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		st.Btime = statxTime(stx.Btime)
+	}
+	if stx.Mask&unix.STATX_MNT_ID != 0 {
+		st.MountID = stx.Mnt_id
+	}
+	return st, nil
+}
+
+func statxTime(ts unix.StatxTimestamp) time.Time {
+	return time.Unix(ts.Sec, int64(ts.Nsec))
+}
+
+// rawFileMode converts a Linux st_mode to an
+// fs.FileMode, as the os package does.
+func rawFileMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0777)
+	switch m & syscall.S_IFMT {
+	case syscall.S_IFBLK:
+		mode |= fs.ModeDevice
+	case syscall.S_IFCHR:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case syscall.S_IFDIR:
+		mode |= fs.ModeDir
+	case syscall.S_IFIFO:
+		mode |= fs.ModeNamedPipe
+	case syscall.S_IFLNK:
+		mode |= fs.ModeSymlink
+	case syscall.S_IFSOCK:
+		mode |= fs.ModeSocket
+	}
+	if m&syscall.S_ISGID != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if m&syscall.S_ISUID != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if m&syscall.S_ISVTX != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
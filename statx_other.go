@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package gofile
+
+// statX fills an ExtStat from the system stat data, as
+// statx(2) is only available on Linux.
+func statX(name string, follow bool) (*ExtStat, error) {
+	return statXFallback(name, follow)
+}
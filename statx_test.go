@@ -0,0 +1,78 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatX(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "file", "sub/")
+	name := filepath.Join(dir, "file")
+	os.Symlink("file", filepath.Join(dir, "link"))
+	stamp := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	os.Chtimes(name, stamp, stamp)
+
+	tests := []struct {
+		name   string
+		fn     func(string) (*ExtStat, error)
+		path   string
+		follow bool
+	}{
+		{"file", StatX, "file", true},
+		{"dir", StatX, "sub", true},
+		{"link", StatX, "link", true},
+		{"lstat link", LstatX, "link", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, tt.path)
+			st, err := tt.fn(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stat := os.Lstat
+			if tt.follow {
+				stat = os.Stat
+			}
+			fi, _ := stat(p)
+			if st.Name != tt.path || st.Size != fi.Size() || st.Mode != fi.Mode() || !st.Mtime.Equal(fi.ModTime()) {
+				t.Errorf("StatX() = %s %d %v %v, want %s %d %v %v",
+					st.Name, st.Size, st.Mode, st.Mtime, tt.path, fi.Size(), fi.Mode(), fi.ModTime())
+			}
+			if sys, ok := statSys(fi); ok {
+				if st.Ino != sys.ino || st.Dev != sys.dev || st.Nlink != sys.nlink || st.Uid != sys.uid || st.Blocks != sys.blocks || st.BlockSize != sys.blksize {
+					t.Errorf("StatX() = %+v, want %+v", st, sys)
+				}
+			}
+		})
+	}
+
+	if st, _ := StatX(name); !st.Atime.Equal(stamp) || !st.Mtime.Equal(stamp) {
+		t.Errorf("StatX() times = %v %v, want %v", st.Atime, st.Mtime, stamp)
+	}
+	// the Stat_t fallback agrees with statx
+	st, err := StatX(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err := statXFallback(name, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.MountID, st.Btime = 0, time.Time{}
+	if !st.Atime.Equal(fb.Atime) || !st.Mtime.Equal(fb.Mtime) || !st.Ctime.Equal(fb.Ctime) {
+		t.Errorf("statXFallback() times = %v %v %v, want %v %v %v", fb.Atime, fb.Mtime, fb.Ctime, st.Atime, st.Mtime, st.Ctime)
+	}
+	st.Atime, st.Mtime, st.Ctime = fb.Atime, fb.Mtime, fb.Ctime
+	if *st != *fb {
+		t.Errorf("statXFallback() = %+v, want %+v", fb, st)
+	}
+
+	if _, err := StatX(filepath.Join(dir, "missing")); !errors.Is(err, ErrNotExist) {
+		t.Errorf("StatX() error = %v, want ErrNotExist", err)
+	}
+}
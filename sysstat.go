@@ -0,0 +1,13 @@
+package gofile
+
+// sysStat holds the fields of the system stat data
+// that are not available from fs.FileInfo.
+type sysStat struct {
+	dev     uint64
+	ino     uint64
+	nlink   uint64
+	uid     uint32
+	gid     uint32
+	blocks  int64 // 512 byte blocks allocated
+	blksize int64 // preferred block size for I/O
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package gofile
+
+import "io/fs"
+
+// statSys reports false; system stat data is only
+// read on Linux, macOS and the BSDs.
+func statSys(fi fs.FileInfo) (sysStat, bool) {
+	return sysStat{}, false
+}
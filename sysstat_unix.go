@@ -0,0 +1,27 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package gofile
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// statSys returns the system stat data of fi and
+// reports whether it was available.
+func statSys(fi fs.FileInfo) (sysStat, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return sysStat{}, false
+	}
+	return sysStat{
+		dev:     uint64(st.Dev),
+		ino:     st.Ino,
+		nlink:   uint64(st.Nlink),
+		uid:     st.Uid,
+		gid:     st.Gid,
+		blocks:  st.Blocks,
+		blksize: int64(st.Blksize),
+	}, true
+}
@@ -0,0 +1,263 @@
+package gofile
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+// TreeStats reports the number of directories and
+// files included in a recursive listing. The listed
+// directory itself is not counted.
+type TreeStats struct {
+	Dirs  int
+	Files int
+}
+
+func (s TreeStats) String() string {
+	return fmt.Sprintf("%d %s, %d %s", s.Dirs, plural(s.Dirs, "directory", "directories"), s.Files, plural(s.Files, "file", "files"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// treeNode is an entry in a recursive listing along
+// with the entries of its subdirectory, if any.
+type treeNode struct {
+	path     string
+	info     fs.FileInfo
+	children []*treeNode
+	expanded bool  // directory was read
+	err      error // error reading the directory
+}
+
+// buildTree reads the directory and its subdirectories
+// to the depth set in the listing options. Each level
+// is sorted using the listing options. Subdirectories
+// are read concurrently by a Walker.
+func (l *dirList) buildTree() *treeNode {
+	root := &treeNode{path: l.Path(), expanded: true}
+	nodes := map[string]*treeNode{root.path: root}
+
+	// read the ignore files once, before the walk, so
+	// that the listings of all directories share them
+	l.ignoreMatcher()
+
+	w := &Walker{Ordered: true, MaxDepth: l.opts.maxDepth, ReadDir: l.readDirEntries, FS: l.fs()}
+	w.Walk(root.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// the directory at path could not be read
+			if n, ok := nodes[path]; ok {
+				n.err = Err(err)
+			}
+			return SkipDir
+		}
+		if path == root.path {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			Err(err)
+			return nil
+		}
+		n := &treeNode{path: path, info: fi}
+		parent := nodes[filepath.Dir(path)]
+		parent.children = append(parent.children, n)
+
+		if d.IsDir() {
+			if isSymlink(l.fs(), path) {
+				return SkipDir
+			}
+			n.expanded = l.opts.maxDepth <= 0 || relDepth(root.path, path) < l.opts.maxDepth
+			nodes[path] = n
+		}
+		return nil
+	})
+
+	if l.opts.prune {
+		pruneTree(root)
+	}
+	return root
+}
+
+// readDirEntries returns the listing of dir, filtered
+// and sorted using the listing options of l. It is used
+// as the ReadDir function of a Walker.
+func (l *dirList) readDirEntries(dir string) ([]fs.DirEntry, error) {
+	list, err := l.sub(dir).List()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, 0, len(list))
+	for _, f := range list {
+		// '.' and '..' are never descended into
+		if _, ok := f.(namedFile); ok {
+			continue
+		}
+		entries = append(entries, infoEntry{f})
+	}
+	return entries, nil
+}
+
+// infoEntry is an fs.DirEntry for a file whose
+// information has already been read.
+type infoEntry struct {
+	fs.FileInfo
+}
+
+func (e infoEntry) Name() string               { return baseName(e.FileInfo) }
+func (e infoEntry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e infoEntry) Info() (fs.FileInfo, error) { return e.FileInfo, nil }
+
+// pruneTree removes directories that contain no files,
+// directly or in any subdirectory. It reports whether
+// n itself is empty.
+func pruneTree(n *treeNode) bool {
+	kept := n.children[:0]
+	for _, child := range n.children {
+		if child.info.IsDir() && child.expanded && child.err == nil && pruneTree(child) {
+			continue
+		}
+		kept = append(kept, child)
+	}
+	n.children = kept
+	return len(kept) == 0
+}
+
+// isSymlink reports whether path is a symbolic link.
+// Symbolic links to directories are not followed in
+// recursive listings.
+func isSymlink(fsys FileSystem, path string) bool {
+	fi, err := fsys.Lstat(path)
+	return err == nil && fi.Mode()&fs.ModeSymlink != 0
+}
+
+// stats counts the directories and files below n.
+func (n *treeNode) stats() (s TreeStats) {
+	for _, child := range n.children {
+		if child.info.IsDir() {
+			s.Dirs++
+		} else {
+			s.Files++
+		}
+		cs := child.stats()
+		s.Dirs += cs.Dirs
+		s.Files += cs.Files
+	}
+	return s
+}
+
+// writeRecursive writes the listing of the directory
+// followed by a section for each subdirectory, in the
+// style of 'ls -R'.
+func (l *dirList) writeRecursive(w io.Writer) (int64, error) {
+	colors := l.colorsFor(w)
+	cw := &countWriter{w: w}
+
+	root := l.buildTree()
+	if root.err != nil {
+		return 0, root.err
+	}
+
+	var section func(n *treeNode, first bool)
+	section = func(n *treeNode, first bool) {
+		if !first {
+			io.WriteString(cw, "\n")
+		}
+		fmt.Fprintf(cw, "%s:\n", n.path)
+		if n.err != nil {
+			fmt.Fprintf(cw, "cannot open directory: %v\n", n.err)
+		}
+		for _, child := range n.children {
+			io.WriteString(cw, l.formatEntry(child.path, child.info, colors))
+		}
+		for _, child := range n.children {
+			if child.expanded {
+				section(child, false)
+			}
+		}
+	}
+	section(root, true)
+
+	if cw.err != nil {
+		return cw.n, NewGoFileError("unable to write directory listing", l.Path(), cw.err)
+	}
+	return cw.n, nil
+}
+
+// WriteTree writes the directory and its subdirectories
+// to w as a tree, in the style of the 'tree' command,
+// followed by a summary of the number of directories
+// and files listed.
+//
+// The depth, prune, sort and color listing options are
+// applied at each level.
+func (l *dirList) WriteTree(w io.Writer) (TreeStats, error) {
+	colors := l.colorsFor(w)
+	cw := &countWriter{w: w}
+
+	root := l.buildTree()
+	if root.err != nil {
+		return TreeStats{}, root.err
+	}
+
+	name := l.providedName
+	if colors != nil {
+		if fi, err := l.fs().Lstat(root.path); err == nil {
+			name = colors.Paint(name, colors.SGR(root.path, fi))
+		}
+	}
+	fmt.Fprintln(cw, name)
+
+	var branch func(n *treeNode, prefix string)
+	branch = func(n *treeNode, prefix string) {
+		for i, child := range n.children {
+			connector, indent := "├── ", "│   "
+			if i == len(n.children)-1 {
+				connector, indent = "└── ", "    "
+			}
+			line := prefix + connector + l.formatName(child.path, child.info, colors)
+			if child.err != nil {
+				line += "  [error opening dir]"
+			}
+			fmt.Fprintln(cw, line)
+			if child.expanded {
+				branch(child, prefix+indent)
+			}
+		}
+	}
+	branch(root, "")
+
+	stats := root.stats()
+	fmt.Fprintf(cw, "\n%v\n", stats)
+
+	if cw.err != nil {
+		return stats, NewGoFileError("unable to write directory tree", l.Path(), cw.err)
+	}
+	return stats, nil
+}
+
+// countWriter counts bytes written to w and records
+// the first error so that a series of writes may be
+// checked once.
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,143 @@
+package gofile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTree creates files (and their parent directories)
+// below dir. Names ending in '/' are created as empty
+// directories.
+func makeTree(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			if err := os.MkdirAll(path, DirMode); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), DirMode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), NormalMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDirList_WriteTree(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, "b.txt", "a/x.txt", "a/sub/y.txt", "empty/inner/", "c/")
+
+	tests := []struct {
+		name    string
+		options []DirOption
+		want    string
+		stats   TreeStats
+	}{
+		{"full", []DirOption{WithDirsFirst(true)}, `root
+├── a/
+│   ├── sub/
+│   │   └── y.txt
+│   └── x.txt
+├── c/
+├── empty/
+│   └── inner/
+└── b.txt
+
+5 directories, 3 files
+`, TreeStats{5, 3}},
+		{"depth", []DirOption{WithDirsFirst(true), WithMaxDepth(1)}, `root
+├── a/
+├── c/
+├── empty/
+└── b.txt
+
+3 directories, 1 file
+`, TreeStats{3, 1}},
+		{"prune", []DirOption{WithPrune(true)}, `root
+├── a/
+│   ├── sub/
+│   │   └── y.txt
+│   └── x.txt
+└── b.txt
+
+2 directories, 3 files
+`, TreeStats{2, 3}},
+		{"files first reversed", []DirOption{WithDirsFirst(false), WithReverse(true), WithMaxDepth(1)}, `root
+├── empty/
+├── c/
+├── b.txt
+└── a/
+
+3 directories, 1 file
+`, TreeStats{3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDIR(dir, tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.(*dirList).providedName = "root"
+
+			buf := &bytes.Buffer{}
+			stats, err := d.WriteTree(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteTree() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if stats != tt.stats {
+				t.Errorf("WriteTree() stats = %v, want %v", stats, tt.stats)
+			}
+		})
+	}
+}
+
+func TestDirList_buildTree_ignore(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, ".gitignore", "a/x.log", "a/b/y.log", "a/b/z.txt")
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), NormalMode); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDIR(dir, WithGitIgnore(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := d.(*dirList)
+	root := l.buildTree()
+	if got := root.stats(); got != (TreeStats{2, 1}) {
+		t.Errorf("buildTree() stats = %v, want 2 directories, 1 file", got)
+	}
+	// the listings of subdirectories share the matcher
+	// built for the root
+	if l.ignore == nil || l.sub(filepath.Join(dir, "a")).ignore != l.ignore {
+		t.Error("buildTree() did not share the ignore matcher")
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"v1.10.0", "v1.9.3", 1},
+		{"a", "a", 0},
+		{"a", "ab", -1},
+		{"file02", "file2", 0},
+	}
+	for _, tt := range tests {
+		if got := versionCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("versionCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package gofile
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Umask returns the file mode creation mask of the
+// process without changing it.
+//
+// On Linux it is read from /proc/self/status. Where
+// that is not available it is read with umask(2), which
+// can only be done by setting it and restoring it; files
+// created by other goroutines in between may then be
+// given the wrong mode. Other Unix systems always use
+// umask(2). Elsewhere, as on Windows, the usual default
+// of 022 is returned.
+func Umask() fs.FileMode {
+	return umask()
+}
+
+// readUmask returns the umask applied to files created
+// with exact, reading it only if it applies.
+func readUmask(exact bool) fs.FileMode {
+	if exact {
+		return 0
+	}
+	return Umask()
+}
+
+// createMode returns the mode passed to the file system
+// when creating a file or directory with perm. The umask
+// is applied here, and so again by the host file system,
+// so that other file systems give the same result.
+func createMode(perm, umask fs.FileMode, exact bool) fs.FileMode {
+	if exact {
+		return perm
+	}
+	return perm &^ umask
+}
+
+// CreateFileFS creates or truncates the named file in
+// fsys, as with os.Create, with the permissions perm
+// less the umask. If exact is set, the mode of the file
+// is set to perm with Chmod, whatever the umask and even
+// if the file already existed.
+func CreateFileFS(fsys FileSystem, name string, perm fs.FileMode, exact bool) (File, error) {
+	return createFile(fsys, name, perm, readUmask(exact), exact)
+}
+
+// createFile is CreateFileFS with the umask given, so
+// that functions creating many files read it once.
+func createFile(fsys FileSystem, name string, perm, umask fs.FileMode, exact bool) (File, error) {
+	f, err := fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, createMode(perm, umask, exact))
+	if err != nil {
+		return nil, NewGoFileError("unable to create file", name, err)
+	}
+	if exact {
+		if err := fsys.Chmod(name, perm); err != nil {
+			f.Close()
+			return nil, NewGoFileError("unable to set file mode", name, err)
+		}
+	}
+	return f, nil
+}
+
+// CreateFile creates or truncates the named file with
+// the permissions perm, as with CreateFileFS.
+func CreateFile(name string, perm fs.FileMode, exact bool) (*os.File, error) {
+	f, err := CreateFileFS(OSFS{}, name, perm, exact)
+	if err != nil {
+		return nil, err
+	}
+	return f.(*os.File), nil
+}
+
+// WriteFileFS writes data to the named file in fsys, as
+// with os.WriteFile, creating it as with CreateFileFS.
+func WriteFileFS(fsys FileSystem, name string, data []byte, perm fs.FileMode, exact bool) error {
+	f, err := CreateFileFS(fsys, name, perm, exact)
+	if err != nil {
+		return err
+	}
+	if n, err := f.Write(data); err != nil || n < len(data) {
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		f.Close()
+		return NewGoFileError("unable to write file", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return NewGoFileError("unable to close file", name, err)
+	}
+	return nil
+}
+
+// MkdirFS creates the named directory in fsys with the
+// permissions perm less the umask, or exactly perm if
+// exact is set.
+func MkdirFS(fsys FileSystem, name string, perm fs.FileMode, exact bool) error {
+	if err := fsys.Mkdir(name, createMode(perm, readUmask(exact), exact)); err != nil {
+		return NewGoFileError("unable to create directory", name, err)
+	}
+	if exact {
+		if err := fsys.Chmod(name, perm); err != nil {
+			return NewGoFileError("unable to set directory mode", name, err)
+		}
+	}
+	return nil
+}
+
+// MkdirAllFS creates the named directory in fsys and any
+// parents that do not exist, as with os.MkdirAll, with
+// the permissions perm less the umask, or exactly perm
+// if exact is set. Only the directories created are
+// given perm; existing ones are not changed.
+func MkdirAllFS(fsys FileSystem, path string, perm fs.FileMode, exact bool) error {
+	return mkdirAll(fsys, path, perm, readUmask(exact), exact)
+}
+
+// mkdirAll is MkdirAllFS with the umask given, as with
+// createFile.
+func mkdirAll(fsys FileSystem, path string, perm, umask fs.FileMode, exact bool) error {
+	var created []string
+	if exact {
+		// find the directories that will be created,
+		// deepest first
+		for dir := filepath.Clean(path); ; {
+			if _, err := fsys.Stat(dir); !errors.Is(err, ErrNotExist) {
+				break
+			}
+			created = append(created, dir)
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	if err := fsys.MkdirAll(path, createMode(perm, umask, exact)); err != nil {
+		return NewGoFileError("unable to create directory", path, err)
+	}
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := fsys.Chmod(created[i], perm); err != nil {
+			return NewGoFileError("unable to set directory mode", created[i], err)
+		}
+	}
+	return nil
+}
+
+// MkdirAll creates the named directory and any parents
+// that do not exist, as with MkdirAllFS.
+func MkdirAll(path string, perm fs.FileMode, exact bool) error {
+	return MkdirAllFS(OSFS{}, path, perm, exact)
+}
@@ -0,0 +1,28 @@
+package gofile
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// umask reads the Umask line of /proc/self/status, which
+// is present from Linux 4.7, and falls back to umask(2).
+func umask() fs.FileMode {
+	if f, err := os.Open("/proc/self/status"); err == nil {
+		defer f.Close()
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			if line := s.Text(); strings.HasPrefix(line, "Umask:") {
+				v := strings.TrimSpace(strings.TrimPrefix(line, "Umask:"))
+				if m, err := strconv.ParseUint(v, 8, 32); err == nil {
+					return fs.FileMode(m) & fs.ModePerm
+				}
+				break
+			}
+		}
+	}
+	return umaskSyscall()
+}
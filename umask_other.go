@@ -0,0 +1,12 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package gofile
+
+import "io/fs"
+
+// umask returns the usual default of 022 on systems
+// without a umask.
+func umask() fs.FileMode {
+	return 022
+}
@@ -0,0 +1,12 @@
+//go:build aix || darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd netbsd openbsd solaris
+
+package gofile
+
+import "io/fs"
+
+// umask reads the umask with umask(2); these systems
+// do not report it otherwise.
+func umask() fs.FileMode {
+	return umaskSyscall()
+}
@@ -0,0 +1,134 @@
+package gofile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUmask(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file")
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fs.FileMode(0777)&^Umask(), fi.Mode().Perm(); got != want {
+		t.Errorf("0777 &^ Umask() = %v, want %v", got, want)
+	}
+}
+
+func TestCreateModes(t *testing.T) {
+	mask := Umask()
+	for _, tt := range []struct {
+		name string
+		fsys FileSystem
+		root string
+	}{
+		{"OSFS", OSFS{}, t.TempDir()},
+		{"MemFS", NewMemFS(), "/work"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys, root := tt.fsys, tt.root
+			if err := fsys.MkdirAll(filepath.Join(root, "a"), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.Chmod(filepath.Join(root, "a"), 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			modeOf := func(name string) fs.FileMode {
+				t.Helper()
+				fi, err := fsys.Stat(filepath.Join(root, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return fi.Mode().Perm()
+			}
+
+			for _, exact := range []bool{false, true} {
+				name := "masked"
+				if exact {
+					name = "exact"
+				}
+				want := fs.FileMode(0777)
+				if !exact {
+					want &^= mask
+				}
+
+				f, err := CreateFileFS(fsys, filepath.Join(root, name), 0777, exact)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.Close()
+				if got := modeOf(name); got != want {
+					t.Errorf("CreateFileFS(%v) mode = %v, want %v", exact, got, want)
+				}
+
+				if err := WriteFileFS(fsys, filepath.Join(root, name+".data"), []byte("x"), 0777, exact); err != nil {
+					t.Fatal(err)
+				}
+				if got := modeOf(name + ".data"); got != want {
+					t.Errorf("WriteFileFS(%v) mode = %v, want %v", exact, got, want)
+				}
+
+				if err := MkdirFS(fsys, filepath.Join(root, name+".dir"), 0777, exact); err != nil {
+					t.Fatal(err)
+				}
+				if got := modeOf(name + ".dir"); got != want {
+					t.Errorf("MkdirFS(%v) mode = %v, want %v", exact, got, want)
+				}
+
+				if err := MkdirAllFS(fsys, filepath.Join(root, "a", name, "c"), 0777, exact); err != nil {
+					t.Fatal(err)
+				}
+				for _, dir := range []string{"a/" + name, "a/" + name + "/c"} {
+					if got := modeOf(dir); got != want {
+						t.Errorf("MkdirAllFS(%v) mode of %s = %v, want %v", exact, dir, got, want)
+					}
+				}
+				if got := modeOf("a"); got != 0700 {
+					t.Errorf("MkdirAllFS(%v) changed existing parent to %v", exact, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyModes(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.WriteFile(src, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(src, 0750); err != nil {
+		t.Fatal(err)
+	}
+	want := fs.FileMode(0750) &^ Umask()
+
+	copies := map[string]func(src, dst string) (int64, error){
+		"Copy":     Copy,
+		"CopyUtil": CopyUtil,
+		"CopyBuffer": func(src, dst string) (int64, error) {
+			return CopyBuffer(src, dst, 0)
+		},
+	}
+	for name, fn := range copies {
+		dst := filepath.Join(dir, name)
+		if _, err := fn(src, dst); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		fi, err := os.Stat(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %v, want %v", name, got, want)
+		}
+	}
+}
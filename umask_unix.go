@@ -0,0 +1,23 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package gofile
+
+import (
+	"io/fs"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes reading the umask with umask(2).
+var umaskMu sync.Mutex
+
+// umaskSyscall reads the umask with umask(2), setting it
+// to zero and restoring it.
+func umaskSyscall() fs.FileMode {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	m := syscall.Umask(0)
+	syscall.Umask(m)
+	return fs.FileMode(m) & fs.ModePerm
+}
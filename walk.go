@@ -0,0 +1,411 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// WalkFunc is the type of the function called by Walk
+// to visit each file or directory. It is called with the
+// same arguments, and with the same meaning, as an
+// fs.WalkDirFunc:
+//
+// The path argument contains the argument to Walk as a
+// prefix. The d argument is the fs.DirEntry for path.
+//
+// If a directory cannot be read, the function is called
+// a second time for that directory with err set.
+//
+// The function may return SkipDir to skip a directory
+// (or, if called for a file, the remaining entries in
+// the file's directory) and SkipAll to stop the walk.
+// Walk returns any other non-nil error immediately.
+type WalkFunc func(path string, d fs.DirEntry, err error) error
+
+// SkipDir is used as a return value from a WalkFunc to
+// indicate that the directory named in the call is to
+// be skipped. It is not returned as an error by Walk.
+var SkipDir = fs.SkipDir
+
+// SkipAll is used as a return value from a WalkFunc to
+// indicate that all remaining files and directories are
+// to be skipped. It is not returned as an error by Walk.
+var SkipAll = errors.New("skip everything and stop the walk")
+
+// Walker walks file trees, reading directories
+// concurrently with a bounded number of workers.
+//
+// The zero value is ready to use and calls the walk
+// function concurrently, in no particular order.
+type Walker struct {
+	// Workers is the maximum number of directories
+	// read at the same time. If Workers <= 0,
+	// runtime.NumCPU() is used.
+	Workers int
+
+	// Ordered causes the walk function to be called
+	// from a single goroutine in the order used by
+	// filepath.WalkDir: depth first, with the entries
+	// of each directory in the order returned by
+	// ReadDir. Directories are still read ahead of
+	// the walk function by the workers.
+	//
+	// If Ordered is false, the walk function is called
+	// concurrently and must be safe for concurrent use.
+	// A directory is always visited before its entries.
+	Ordered bool
+
+	// MaxDepth limits the walk to entries at most
+	// MaxDepth levels below the root. Directories at
+	// the limit are visited but not read. If MaxDepth
+	// <= 0, there is no limit.
+	MaxDepth int
+
+	// ReadDir returns the entries of a directory. If
+	// ReadDir is nil, the ReadDir method of FS is used,
+	// which for OSFS returns entries sorted by name.
+	ReadDir func(dir string) ([]fs.DirEntry, error)
+
+	// Ignore, if not nil, skips files and directories
+	// that it matches; the walk function is not called
+	// for them.
+	Ignore *IgnoreMatcher
+
+	// FS is the file system walked. If FS is nil,
+	// OSFS is used.
+	FS FileSystem
+}
+
+// Walk walks the file tree rooted at root using the
+// default Walker, calling fn concurrently for each
+// file or directory in the tree, including root.
+func Walk(root string, fn WalkFunc) error {
+	return (&Walker{}).Walk(root, fn)
+}
+
+// Walk walks the file tree rooted at root, calling fn
+// for each file or directory in the tree, including
+// root. Symbolic links are not followed.
+func (w *Walker) Walk(root string, fn WalkFunc) error {
+	fi, err := w.fs().Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		d := fs.FileInfoToDirEntry(fi)
+		err = fn(root, d, nil)
+		if err == nil && d.IsDir() && !w.atMaxDepth(0) {
+			if w.Ordered {
+				err = w.walkOrdered(root, d, fn)
+			} else {
+				err = w.walkParallel(root, d, fn)
+			}
+		}
+	}
+	if err == SkipDir || err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (w *Walker) fs() FileSystem {
+	return fsOrOS(w.FS)
+}
+
+func (w *Walker) workers() int {
+	if w.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return w.Workers
+}
+
+// readDir returns the entries of dir that are not
+// ignored.
+func (w *Walker) readDir(dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	var err error
+	if w.ReadDir != nil {
+		entries, err = w.ReadDir(dir)
+	} else {
+		entries, err = w.fs().ReadDir(dir)
+	}
+	if w.Ignore == nil {
+		return entries, err
+	}
+
+	kept := entries[:0]
+	for _, e := range entries {
+		if !w.Ignore.Match(filepath.Join(dir, e.Name()), e.IsDir()) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, err
+}
+
+// atMaxDepth reports whether directories at depth
+// should not be read.
+func (w *Walker) atMaxDepth(depth int) bool {
+	return w.MaxDepth > 0 && depth >= w.MaxDepth
+}
+
+// walkJob is a directory waiting to be read.
+type walkJob struct {
+	path  string
+	d     fs.DirEntry
+	depth int
+}
+
+// walkState is the shared state of a parallel walk:
+// a stack of directories waiting to be read and the
+// first error that stopped the walk.
+type walkState struct {
+	w  *Walker
+	fn WalkFunc
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    []walkJob
+	pending int // jobs queued or in progress
+	stopped bool
+	err     error
+}
+
+func (w *Walker) walkParallel(root string, d fs.DirEntry, fn WalkFunc) error {
+	s := &walkState{w: w, fn: fn}
+	s.cond = sync.NewCond(&s.mu)
+	s.push(walkJob{path: root, d: d})
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, ok := s.pop()
+				if !ok {
+					return
+				}
+				if err := s.visit(job); err != nil {
+					s.stop(err)
+				}
+				s.done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return s.err
+}
+
+func (s *walkState) push(job walkJob) {
+	s.mu.Lock()
+	if !s.stopped {
+		s.jobs = append(s.jobs, job)
+		s.pending++
+		s.cond.Signal()
+	}
+	s.mu.Unlock()
+}
+
+// pop returns the most recently queued directory,
+// waiting while other workers may still queue more.
+func (s *walkState) pop() (walkJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.jobs) == 0 && s.pending > 0 && !s.stopped {
+		s.cond.Wait()
+	}
+	if s.stopped || len(s.jobs) == 0 {
+		return walkJob{}, false
+	}
+	job := s.jobs[len(s.jobs)-1]
+	s.jobs = s.jobs[:len(s.jobs)-1]
+	return job, true
+}
+
+func (s *walkState) done() {
+	s.mu.Lock()
+	s.pending--
+	if s.pending == 0 {
+		s.cond.Broadcast()
+	}
+	s.mu.Unlock()
+}
+
+func (s *walkState) stop(err error) {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		s.jobs = nil
+		s.err = err
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+func (s *walkState) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped
+}
+
+// visit reads a directory and calls the walk function
+// for each of its entries, queueing subdirectories.
+func (s *walkState) visit(job walkJob) error {
+	entries, err := s.w.readDir(job.path)
+	if err != nil {
+		err = s.fn(job.path, job.d, err)
+		if err == SkipDir {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		if s.isStopped() {
+			return nil
+		}
+		path := filepath.Join(job.path, e.Name())
+		err := s.fn(path, e, nil)
+		if err == SkipDir {
+			if e.IsDir() {
+				continue
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if e.IsDir() && !s.w.atMaxDepth(job.depth+1) {
+			s.push(walkJob{path: path, d: e, depth: job.depth + 1})
+		}
+	}
+	return nil
+}
+
+// dirRead is the result of reading a directory ahead
+// of an ordered walk. The walk claims reads that no
+// worker has started and performs them itself.
+type dirRead struct {
+	path    string
+	mu      sync.Mutex
+	started bool
+	done    chan struct{}
+	entries []fs.DirEntry
+	err     error
+}
+
+// claim reports whether the caller is the first to
+// start the read.
+func (r *dirRead) claim() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return false
+	}
+	r.started = true
+	return true
+}
+
+func (r *dirRead) run(w *Walker) {
+	r.entries, r.err = w.readDir(r.path)
+	close(r.done)
+}
+
+// result returns the entries of the directory,
+// reading it now if no worker has started.
+func (r *dirRead) result(w *Walker) ([]fs.DirEntry, error) {
+	if r.claim() {
+		r.run(w)
+	}
+	<-r.done
+	return r.entries, r.err
+}
+
+func (w *Walker) walkOrdered(root string, d fs.DirEntry, fn WalkFunc) error {
+	queue := make(chan *dirRead, 64*w.workers())
+	quit := make(chan struct{})
+	defer close(quit)
+
+	for i := 0; i < w.workers(); i++ {
+		go func() {
+			for {
+				select {
+				case r := <-queue:
+					if r.claim() {
+						r.run(w)
+					}
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
+
+	prefetch := func(path string) *dirRead {
+		r := &dirRead{path: path, done: make(chan struct{})}
+		select {
+		case queue <- r:
+		default:
+			// queue full; the walk reads it when needed
+		}
+		return r
+	}
+
+	var walk func(path string, d fs.DirEntry, r *dirRead, depth int) error
+	walk = func(path string, d fs.DirEntry, r *dirRead, depth int) error {
+		entries, err := r.result(w)
+		if err != nil {
+			err = fn(path, d, err)
+			if err != nil {
+				return err
+			}
+		}
+
+		reads := make([]*dirRead, len(entries))
+		for i, e := range entries {
+			if e.IsDir() && !w.atMaxDepth(depth+1) {
+				reads[i] = prefetch(filepath.Join(path, e.Name()))
+			}
+		}
+
+		for i, e := range entries {
+			p := filepath.Join(path, e.Name())
+			err := fn(p, e, nil)
+			if err == nil && reads[i] != nil {
+				err = walk(p, e, reads[i], depth+1)
+			}
+			if err == SkipDir {
+				if e.IsDir() {
+					continue
+				}
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := walk(root, d, prefetch(root), 0)
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// relDepth returns the number of path elements of
+// path below root.
+func relDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
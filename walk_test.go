@@ -0,0 +1,148 @@
+package gofile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+var walkTestFiles = []string{"a/1", "a/2", "a/b/3", "a/b/c/4", "d/5", "e/", "f"}
+
+// walkPaths returns the paths visited by w below root,
+// relative to root, in the order visited.
+func walkPaths(t *testing.T, w *Walker, root string, fn func(rel string, d fs.DirEntry) error) []string {
+	t.Helper()
+	var mu sync.Mutex
+	var got []string
+	err := w.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		mu.Lock()
+		got = append(got, filepath.ToSlash(rel))
+		mu.Unlock()
+		if fn != nil {
+			return fn(rel, d)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestWalker_Walk(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, walkTestFiles...)
+
+	var want []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		rel, _ := filepath.Rel(root, path)
+		want = append(want, filepath.ToSlash(rel))
+		return nil
+	})
+
+	for _, workers := range []int{1, 2, 8} {
+		w := &Walker{Workers: workers, Ordered: true}
+		if got := walkPaths(t, w, root, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("ordered walk with %d workers = %v, want %v", workers, got, want)
+		}
+
+		w.Ordered = false
+		got := walkPaths(t, w, root, nil)
+		sort.Strings(got)
+		sorted := append([]string(nil), want...)
+		sort.Strings(sorted)
+		if !reflect.DeepEqual(got, sorted) {
+			t.Errorf("parallel walk with %d workers = %v, want %v", workers, got, sorted)
+		}
+	}
+}
+
+func TestWalker_skip(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, walkTestFiles...)
+
+	tests := []struct {
+		name string
+		w    *Walker
+		fn   func(rel string, d fs.DirEntry) error
+		want []string
+	}{
+		{"SkipDir", &Walker{Ordered: true}, func(rel string, d fs.DirEntry) error {
+			if rel == "a" {
+				return SkipDir
+			}
+			return nil
+		}, []string{".", "a", "d", "d/5", "e", "f"}},
+		{"SkipDir on file", &Walker{Ordered: true}, func(rel string, d fs.DirEntry) error {
+			if rel == filepath.FromSlash("a/1") {
+				return SkipDir
+			}
+			return nil
+		}, []string{".", "a", "a/1", "d", "d/5", "e", "f"}},
+		{"SkipAll", &Walker{Ordered: true}, func(rel string, d fs.DirEntry) error {
+			if rel == "d" {
+				return SkipAll
+			}
+			return nil
+		}, []string{".", "a", "a/1", "a/2", "a/b", "a/b/3", "a/b/c", "a/b/c/4", "d"}},
+		{"MaxDepth", &Walker{Ordered: true, MaxDepth: 2}, nil,
+			[]string{".", "a", "a/1", "a/2", "a/b", "d", "d/5", "e", "f"}},
+		{"parallel SkipDir", &Walker{Workers: 4}, func(rel string, d fs.DirEntry) error {
+			if rel == "a" {
+				return SkipDir
+			}
+			return nil
+		}, []string{".", "a", "d", "d/5", "e", "f"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := walkPaths(t, tt.w, root, tt.fn)
+			if !tt.w.Ordered {
+				sort.Strings(got)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Walk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src := t.TempDir()
+	makeTree(t, src, walkTestFiles...)
+	if err := os.Symlink("f", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "copy")
+
+	n, err := CopyTree(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want int64
+	for _, name := range walkTestFiles {
+		if name[len(name)-1] != '/' {
+			want += int64(len(name))
+		}
+	}
+	if n != want {
+		t.Errorf("CopyTree() = %d bytes, want %d", n, want)
+	}
+
+	w := &Walker{Ordered: true}
+	if got, want := walkPaths(t, w, dst, nil), walkPaths(t, w, src, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyTree() copied %v, want %v", got, want)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "f" {
+		t.Errorf("CopyTree() link = %q, %v, want %q", link, err, "f")
+	}
+}
@@ -0,0 +1,275 @@
+package gofile
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op is a set of file system operations reported by
+// a Watcher.
+type Op uint32
+
+const (
+	Create Op = 1 << iota
+	Write
+	Remove
+	Rename
+	Chmod
+)
+
+var opNames = []struct {
+	op   Op
+	name string
+}{
+	{Create, "CREATE"},
+	{Write, "WRITE"},
+	{Remove, "REMOVE"},
+	{Rename, "RENAME"},
+	{Chmod, "CHMOD"},
+}
+
+// String returns the names of the operations in op
+// separated by '|', e.g. "CREATE|WRITE".
+func (op Op) String() string {
+	var names []string
+	for _, n := range opNames {
+		if op&n.op != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Has reports whether op includes all of the
+// operations in other.
+func (op Op) Has(other Op) bool {
+	return op&other == other
+}
+
+// Event is a change to a watched file. A file that is
+// renamed is reported as Rename at the old name and
+// Create at the new name, if that is watched.
+type Event struct {
+	Name string // path of the file, including the watched path
+	Op   Op
+}
+
+func (e Event) String() string {
+	return e.Op.String() + " " + e.Name
+}
+
+// ErrEventOverflow is sent on the Errors channel of a
+// Watcher when events were lost because they arrived
+// faster than they were read.
+var ErrEventOverflow = errors.New("file system event queue overflow")
+
+// DefaultPollInterval is the interval between scans
+// of a polling Watcher.
+const DefaultPollInterval = time.Second
+
+// WatchOptions are the options for NewWatcher.
+type WatchOptions struct {
+	// Recursive watches the subdirectories of watched
+	// directories, including those created later.
+	Recursive bool
+
+	// Debounce combines the events for each file that
+	// occur within the window after the first into a
+	// single event with all of their operations. If
+	// Debounce is zero, events are sent as they occur.
+	Debounce time.Duration
+
+	// Poll uses a stat based poller even if inotify is
+	// available, e.g. on network file systems.
+	Poll bool
+
+	// PollInterval is the interval between scans of
+	// the poller. If zero, DefaultPollInterval is used.
+	PollInterval time.Duration
+}
+
+// watchBackend is a source of file system events: inotify
+// or the poller. Events are sent on the channel given when
+// the backend is created, which is closed when the backend
+// is closed.
+type watchBackend interface {
+	add(path string) error
+	remove(path string) error
+	close() error
+}
+
+// Watcher reports changes to watched files and
+// directories. It uses inotify on Linux and polls
+// elsewhere, or if inotify cannot be started.
+//
+// Events and Errors must be read until they are closed
+// by Close; the watcher stops reporting changes while
+// they are not read.
+type Watcher struct {
+	Events <-chan Event
+	Errors <-chan error
+
+	backend watchBackend
+	opts    WatchOptions
+
+	closeOnce sync.Once
+	done      chan struct{}
+	finished  chan struct{}
+}
+
+// NewWatcher returns a Watcher with no watched paths.
+func NewWatcher(opts WatchOptions) (*Watcher, error) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	raw := make(chan Event, 64)
+	rawErrs := make(chan error, 8)
+	events := make(chan Event, 64)
+	errs := make(chan error, 8)
+	w := &Watcher{
+		Events:   events,
+		Errors:   errs,
+		opts:     opts,
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+
+	if !opts.Poll {
+		// on error, w.backend is nil and the poller is used
+		w.backend, _ = newInotify(opts, raw, rawErrs, w.done)
+	}
+	if w.backend == nil {
+		w.backend = newPoller(opts, raw, rawErrs, w.done)
+	}
+
+	go w.debounce(raw, rawErrs, events, errs)
+	return w, nil
+}
+
+// Add watches the named file or directory. Events for
+// a directory include events for its entries.
+func (w *Watcher) Add(name string) error {
+	return w.backend.add(name)
+}
+
+// Remove stops watching the named file or directory,
+// and its subdirectories in a recursive watch.
+func (w *Watcher) Remove(name string) error {
+	return w.backend.remove(name)
+}
+
+// Close stops the watcher and closes the Events and
+// Errors channels.
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.backend.close()
+		<-w.finished
+	})
+	return err
+}
+
+// debounce forwards events from raw to events,
+// combining events for the same file within the
+// debounce window, and errors from rawErrs to errs,
+// until raw is closed. Pending events are sent before
+// an error and when raw is closed. Events are dropped
+// once the watcher is closed, but raw is drained so
+// that the backend can finish.
+func (w *Watcher) debounce(raw <-chan Event, rawErrs <-chan error, events chan<- Event, errs chan<- error) {
+	defer close(w.finished)
+	defer close(errs)
+	defer close(events)
+
+	stopped := false
+	send := func(e Event) {
+		if stopped {
+			return
+		}
+		select {
+		case events <- e:
+		case <-w.done:
+			stopped = true
+		}
+	}
+	sendErr := func(err error) {
+		if stopped {
+			return
+		}
+		select {
+		case errs <- err:
+		case <-w.done:
+			stopped = true
+		}
+	}
+
+	var (
+		pending = make(map[string]int) // name -> index in queue
+		queue   []Event
+		expired <-chan time.Time
+	)
+	add := func(e Event) {
+		if w.opts.Debounce <= 0 {
+			send(e)
+			return
+		}
+		if i, ok := pending[e.Name]; ok {
+			queue[i].Op |= e.Op
+			return
+		}
+		pending[e.Name] = len(queue)
+		queue = append(queue, e)
+		if expired == nil {
+			expired = time.After(w.opts.Debounce)
+		}
+	}
+	flush := func() {
+		for _, e := range queue {
+			send(e)
+		}
+		pending = make(map[string]int)
+		queue = nil
+		expired = nil
+	}
+
+	for {
+		select {
+		case e, ok := <-raw:
+			if !ok {
+				flush()
+				for {
+					select {
+					case err := <-rawErrs:
+						sendErr(err)
+					default:
+						return
+					}
+				}
+			}
+			add(e)
+		case err := <-rawErrs:
+			// the backend sent the events already in raw
+			// before the error
+		drain:
+			for {
+				select {
+				case e, ok := <-raw:
+					if !ok {
+						break drain
+					}
+					add(e)
+				default:
+					break drain
+				}
+			}
+			flush()
+			sendErr(err)
+		case <-expired:
+			flush()
+		}
+	}
+}
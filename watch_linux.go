@@ -0,0 +1,259 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the set of inotify events watched.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_DELETE_SELF |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_MOVE_SELF
+
+// inotify is a watchBackend using Linux inotify. In a
+// recursive watch, a watch is added for each directory
+// as it appears.
+type inotify struct {
+	opts   WatchOptions
+	f      *os.File
+	fd     int
+	events chan<- Event
+	errs   chan<- error
+	done   <-chan struct{}
+	exited chan struct{}
+
+	mu      sync.Mutex
+	watches map[int]string // watch descriptor -> path
+	paths   map[string]int // path -> watch descriptor
+}
+
+func newInotify(opts WatchOptions, events chan<- Event, errs chan<- error, done <-chan struct{}) (watchBackend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, NewGoFileError("unable to initialize inotify", "", err)
+	}
+	w := &inotify{
+		opts: opts,
+		// a non-blocking file uses the runtime poller, so
+		// that Close interrupts a pending Read
+		f:       os.NewFile(uintptr(fd), "inotify"),
+		fd:      fd,
+		events:  events,
+		errs:    errs,
+		done:    done,
+		exited:  make(chan struct{}),
+		watches: make(map[int]string),
+		paths:   make(map[string]int),
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *inotify) add(name string) error {
+	name = filepath.Clean(name)
+	fi, err := os.Lstat(name)
+	if err != nil {
+		return NewGoFileError("unable to stat watched path", name, err)
+	}
+	if fi.IsDir() && w.opts.Recursive {
+		_, err = w.addTree(name)
+		return err
+	}
+	return w.addWatch(name)
+}
+
+// addTree watches root and its subdirectories and
+// returns the paths found below root.
+func (w *inotify) addTree(root string) ([]string, error) {
+	var found []string
+	err := (&Walker{Ordered: true, Workers: 1}).Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // removed or unreadable; skip it
+		}
+		if path != root {
+			found = append(found, path)
+		}
+		if d.IsDir() {
+			return w.addWatch(path)
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (w *inotify) addWatch(path string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+	if err != nil {
+		return NewGoFileError("unable to add inotify watch", path, err)
+	}
+	w.mu.Lock()
+	w.watches[wd] = path
+	w.paths[path] = wd
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *inotify) remove(name string) error {
+	name = filepath.Clean(name)
+	w.mu.Lock()
+	_, ok := w.paths[name]
+	w.mu.Unlock()
+	if !ok {
+		return NewGoFileError("path is not watched", name, ErrNotExist)
+	}
+	w.removeTree(name)
+	return nil
+}
+
+// removeTree removes the watches for root and the
+// paths below it.
+func (w *inotify) removeTree(root string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	prefix := root + string(filepath.Separator)
+	for path, wd := range w.paths {
+		if path == root || strings.HasPrefix(path, prefix) {
+			// the watch may already be gone with its directory
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.paths, path)
+			delete(w.watches, wd)
+		}
+	}
+}
+
+func (w *inotify) close() error {
+	err := w.f.Close()
+	<-w.exited
+	if err != nil {
+		return NewGoFileError("unable to close inotify", "", err)
+	}
+	return nil
+}
+
+func (w *inotify) run() {
+	defer close(w.exited)
+	defer close(w.events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.sendErr(NewGoFileError("unable to read inotify events", "", err))
+			}
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[start:start+int(raw.Len)]), "\x00")
+			off = start + int(raw.Len)
+
+			if !w.handle(int(raw.Wd), raw.Mask, name) {
+				return
+			}
+		}
+	}
+}
+
+// handle sends the events for one inotify event. It
+// reports false if the watcher was closed.
+func (w *inotify) handle(wd int, mask uint32, name string) bool {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		return w.sendErr(ErrEventOverflow)
+	}
+
+	w.mu.Lock()
+	dir, ok := w.watches[wd]
+	if ok && mask&syscall.IN_IGNORED != 0 {
+		delete(w.watches, wd)
+		if w.paths[dir] == wd {
+			delete(w.paths, dir)
+		}
+	}
+	_, parentWatched := w.paths[filepath.Dir(dir)]
+	w.mu.Unlock()
+	if !ok || mask&syscall.IN_IGNORED != 0 {
+		return true
+	}
+
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+	isDir := mask&syscall.IN_ISDIR != 0
+
+	var op Op
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		op = Create
+	case mask&syscall.IN_MODIFY != 0:
+		op = Write
+	case mask&syscall.IN_ATTRIB != 0:
+		op = Chmod
+	case mask&syscall.IN_DELETE != 0:
+		op = Remove
+	case mask&syscall.IN_MOVED_FROM != 0:
+		op = Rename
+		if isDir {
+			w.removeTree(path)
+		}
+	case mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0:
+		if mask&syscall.IN_MOVE_SELF != 0 {
+			w.removeTree(path)
+		}
+		if parentWatched {
+			return true // reported by the watch on the parent
+		}
+		op = Remove
+		if mask&syscall.IN_MOVE_SELF != 0 {
+			op = Rename
+		}
+	default:
+		return true
+	}
+
+	if !w.send(Event{Name: path, Op: op}) {
+		return false
+	}
+
+	// watch new directories, and report entries created
+	// before the watch was added
+	if op == Create && isDir && w.opts.Recursive {
+		found, err := w.addTree(path)
+		if err != nil && !w.sendErr(err) {
+			return false
+		}
+		for _, p := range found {
+			if !w.send(Event{Name: p, Op: Create}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (w *inotify) send(e Event) bool {
+	select {
+	case w.events <- e:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *inotify) sendErr(err error) bool {
+	select {
+	case w.errs <- err:
+		return true
+	case <-w.done:
+		return false
+	}
+}
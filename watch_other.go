@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package gofile
+
+// newInotify returns an error; inotify is only
+// available on Linux, so watchers poll instead.
+func newInotify(opts WatchOptions, events chan<- Event, errs chan<- error, done <-chan struct{}) (watchBackend, error) {
+	return nil, NewGoFileError("inotify is only available on Linux", "", ErrNotImplemented)
+}
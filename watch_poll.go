@@ -0,0 +1,213 @@
+package gofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// poller is a watchBackend that scans the watched
+// paths at an interval and reports the differences
+// from the previous scan.
+type poller struct {
+	opts   WatchOptions
+	events chan<- Event
+	errs   chan<- error
+	done   <-chan struct{}
+	exited chan struct{}
+
+	mu    sync.Mutex
+	roots map[string]map[string]fs.FileInfo // watched path -> path -> info
+}
+
+func newPoller(opts WatchOptions, events chan<- Event, errs chan<- error, done <-chan struct{}) *poller {
+	p := &poller{
+		opts:   opts,
+		events: events,
+		errs:   errs,
+		done:   done,
+		exited: make(chan struct{}),
+		roots:  make(map[string]map[string]fs.FileInfo),
+	}
+	go p.run()
+	return p
+}
+
+func (p *poller) add(name string) error {
+	name = filepath.Clean(name)
+	state, err := p.scan(name)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roots[name] = state
+	return nil
+}
+
+func (p *poller) remove(name string) error {
+	name = filepath.Clean(name)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.roots[name]; !ok {
+		return NewGoFileError("path is not watched", name, ErrNotExist)
+	}
+	delete(p.roots, name)
+	return nil
+}
+
+func (p *poller) close() error {
+	<-p.exited
+	return nil
+}
+
+func (p *poller) run() {
+	defer close(p.exited)
+	defer close(p.events)
+
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !p.poll() {
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// poll scans each watched path and sends the changes.
+// It reports false if the watcher was closed.
+func (p *poller) poll() bool {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.roots))
+	for name := range p.roots {
+		names = append(names, name)
+	}
+	p.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		state, err := p.scan(name)
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			select {
+			case p.errs <- err:
+			case <-p.done:
+				return false
+			}
+			continue
+		}
+
+		p.mu.Lock()
+		prev, ok := p.roots[name]
+		if ok {
+			p.roots[name] = state
+		}
+		p.mu.Unlock()
+		if !ok {
+			continue // removed during the scan
+		}
+
+		for _, e := range pollChanges(prev, state) {
+			select {
+			case p.events <- e:
+			case <-p.done:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// scan returns the file information for root and, if
+// it is a directory, its entries. A missing root has
+// no entries.
+func (p *poller) scan(root string) (map[string]fs.FileInfo, error) {
+	state := make(map[string]fs.FileInfo)
+	fi, err := os.Lstat(root)
+	if err != nil {
+		return state, NewGoFileError("unable to stat watched path", root, err)
+	}
+	state[root] = fi
+	if !fi.IsDir() {
+		return state, nil
+	}
+
+	w := &Walker{Ordered: true, Workers: 1}
+	if !p.opts.Recursive {
+		w.MaxDepth = 1
+	}
+	err = w.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil // missing or unreadable directories have no entries
+		}
+		if fi, err := d.Info(); err == nil {
+			state[path] = fi
+		}
+		return nil
+	})
+	return state, err
+}
+
+// pollChanges returns the events that change prev into
+// next, sorted by name. A removed file with the same
+// inode, size and modification time as a created file
+// is reported as renamed.
+func pollChanges(prev, next map[string]fs.FileInfo) []Event {
+	var events []Event
+	removed := make(map[uint64]int) // inode -> index in events
+	infos := make(map[uint64]fs.FileInfo)
+	for name, old := range prev {
+		if _, ok := next[name]; ok {
+			continue
+		}
+		if st, ok := statSys(old); ok {
+			removed[st.ino] = len(events)
+			infos[st.ino] = old
+		}
+		events = append(events, Event{Name: name, Op: Remove})
+	}
+
+	for name, fi := range next {
+		old, ok := prev[name]
+		if !ok {
+			if st, ok := statSys(fi); ok {
+				// inodes of removed files are soon reused
+				if i, ok := removed[st.ino]; ok && sameFile(infos[st.ino], fi) {
+					events[i].Op = Rename
+				}
+			}
+			events = append(events, Event{Name: name, Op: Create})
+			continue
+		}
+
+		var op Op
+		if old.Mode() != fi.Mode() {
+			op |= Chmod
+		}
+		if !fi.IsDir() && (old.Size() != fi.Size() || !old.ModTime().Equal(fi.ModTime())) {
+			op |= Write
+		}
+		if op != 0 {
+			events = append(events, Event{Name: name, Op: op})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events
+}
+
+// sameFile reports whether a and b have the same type,
+// size and modification time.
+func sameFile(a, b fs.FileInfo) bool {
+	return a.Mode().Type() == b.Mode().Type() && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
@@ -0,0 +1,150 @@
+package gofile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		name := "inotify"
+		if poll {
+			name = "poll"
+		}
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			makeTree(t, root, "old", "chmod", "sub/")
+
+			w, err := NewWatcher(WatchOptions{
+				Recursive:    true,
+				Debounce:     100 * time.Millisecond,
+				Poll:         poll,
+				PollInterval: 20 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			if err := w.Add(root); err != nil {
+				t.Fatal(err)
+			}
+
+			join := func(name string) string { return filepath.Join(root, name) }
+			// a burst of writes, combined by the debounce window
+			f, err := os.Create(join("sub/new"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 10; i++ {
+				f.WriteString("data\n")
+			}
+			f.Close()
+			os.Remove(join("old"))
+			os.Chmod(join("chmod"), 0600)
+			os.Mkdir(join("sub/deeper"), DirMode)
+			os.WriteFile(join("sub/deeper/file"), []byte("file"), NormalMode)
+
+			want := map[string]Op{
+				join("sub/new"):         Create,
+				join("old"):             Remove,
+				join("chmod"):           Chmod,
+				join("sub/deeper/file"): Create,
+			}
+			got := make(map[string]Op)
+			timeout := time.After(5 * time.Second)
+			for len(got) < len(want) || !hasOps(got, want) {
+				select {
+				case e := <-w.Events:
+					got[e.Name] |= e.Op
+				case err := <-w.Errors:
+					t.Fatal(err)
+				case <-timeout:
+					t.Fatalf("events = %v, want %v", got, want)
+				}
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			// Close closes the channels
+			for range w.Events {
+			}
+		})
+	}
+}
+
+// hasOps reports whether got includes the operations
+// in want for each name.
+func hasOps(got, want map[string]Op) bool {
+	for name, op := range want {
+		if !got[name].Has(op) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPollChanges(t *testing.T) {
+	a := fakeInfo{name: "a", size: 1}
+	b := fakeInfo{name: "b", size: 1}
+	prev := map[string]os.FileInfo{"a": a, "b": b, "c": fakeInfo{name: "c"}}
+	b.size = 2
+	next := map[string]os.FileInfo{"a": a, "b": b, "d": fakeInfo{name: "d"}}
+
+	got := pollChanges(prev, next)
+	want := []Event{{"b", Write}, {"c", Remove}, {"d", Create}}
+	if len(got) != len(want) {
+		t.Fatalf("pollChanges() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pollChanges()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if s := (Create | Write).String(); s != "CREATE|WRITE" {
+		t.Errorf("Op.String() = %q, want CREATE|WRITE", s)
+	}
+}
+
+func TestWatcher_debounceFlush(t *testing.T) {
+	w := &Watcher{
+		opts:     WatchOptions{Debounce: time.Hour},
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	raw := make(chan Event, 8)
+	rawErrs := make(chan error, 8)
+	events := make(chan Event, 8)
+	errs := make(chan error, 8)
+	go w.debounce(raw, rawErrs, events, errs)
+
+	failed := errors.New("backend failed")
+	raw <- Event{"a", Create}
+	raw <- Event{"a", Write}
+	rawErrs <- failed
+	raw <- Event{"b", Remove}
+	close(raw)
+
+	// pending events are sent before the error and when
+	// raw is closed, without waiting for the window
+	timeout := time.After(5 * time.Second)
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+	select {
+	case err := <-errs:
+		if err != failed {
+			t.Errorf("error = %v, want %v", err, failed)
+		}
+	case <-timeout:
+		t.Fatal("timed out waiting for the error")
+	}
+	want := []Event{{"a", Create | Write}, {"b", Remove}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}